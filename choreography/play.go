@@ -0,0 +1,99 @@
+package choreography
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
+)
+
+// ErrAborted is returned when the show has been stopped before the end
+var ErrAborted = errors.New("the show has been aborted")
+
+// lateTolerance is how late a command can be sent before it's reported
+const lateTolerance = 100 * time.Millisecond
+
+// Performer executes the commands of the show on the individual drones
+type Performer interface {
+	// Perform executes the command on the named drone and blocks until the drone has been instructed
+	Perform(drone string, cmd input.Command) error
+}
+
+// Play runs the show and blocks until all the drones have finished their moves.
+//
+// The drones have no clock of their own, so all the commands are scheduled against the host clock from a common start time,
+// which is lead after Play is called. Every drone is driven by its own goroutine, so a slow drone does not hold up the others.
+// If a command fails or the show is stopped, all the drones are asked to land. The show is not played if it does not pass the validation.
+func (s *Show) Play(performer Performer, lead time.Duration, logger *logging.Logger, stop <-chan interface{}) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	start := time.Now().Add(lead)
+	logger.Infof("Show: %s starts at %s", s.Name, start.Format("15:04:05.000"))
+
+	abort := make(chan interface{})
+	var abortOnce sync.Once
+	var errMux sync.Mutex
+	var failure error
+	fail := func(err error) {
+		errMux.Lock()
+		if failure == nil {
+			failure = err
+		}
+		errMux.Unlock()
+		abortOnce.Do(func() { close(abort) })
+	}
+
+	go func() {
+		select {
+		case <-stop:
+			fail(ErrAborted)
+		case <-abort:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, t := range s.Tracks {
+		wg.Add(1)
+		go func(t Track) {
+			defer wg.Done()
+			for _, step := range t.Steps {
+				due := start.Add(step.Time())
+				timer := time.NewTimer(time.Until(due))
+				select {
+				case <-abort:
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+				if late := time.Since(due); late > lateTolerance {
+					logger.Log(logging.Warn, logging.Fields{"drone": t.Drone, "command": step.command, "late": late}, "Show: %s %s is %s late", t.Drone, step.command, late)
+				}
+				if err := performer.Perform(t.Drone, step.command); err != nil {
+					fail(fmt.Errorf("%s: %s at %.1fs failed: %s", t.Drone, step.command, step.At, err))
+					return
+				}
+			}
+		}(t)
+	}
+	wg.Wait()
+	abortOnce.Do(func() { close(abort) })
+
+	errMux.Lock()
+	err := failure
+	errMux.Unlock()
+	if err != nil {
+		logger.Errorf("Show: %s, landing all the drones", err)
+		for _, t := range s.Tracks {
+			if err := performer.Perform(t.Drone, input.Land); err != nil {
+				logger.Errorf("Show: failed to land %s: %s", t.Drone, err)
+			}
+		}
+		return err
+	}
+	logger.Infof("Show: %s finished", s.Name)
+	return nil
+}
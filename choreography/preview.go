@@ -0,0 +1,165 @@
+package choreography
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// colours are the colours of the drone paths in the SVG preview
+var colours = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf"}
+
+// bounds is the area covered by the paths on the ground plane
+type bounds struct {
+	minX, maxX, minY, maxY float64
+}
+
+func (s *Show) bounds(paths [][]Position) bounds {
+	b := bounds{minX: math.Inf(1), maxX: math.Inf(-1), minY: math.Inf(1), maxY: math.Inf(-1)}
+	for _, path := range paths {
+		for _, p := range path {
+			b.minX, b.maxX = math.Min(b.minX, p.X), math.Max(b.maxX, p.X)
+			b.minY, b.maxY = math.Min(b.minY, p.Y), math.Max(b.maxY, p.Y)
+		}
+	}
+	// Leave some room around the paths, so the drones on the edges are visible
+	margin := math.Max(s.MinDistance, s.MoveDistance) / 2
+	b.minX, b.maxX, b.minY, b.maxY = b.minX-margin, b.maxX+margin, b.minY-margin, b.maxY+margin
+	return b
+}
+
+func (s *Show) paths() [][]Position {
+	paths := make([][]Position, len(s.Tracks))
+	for i, t := range s.Tracks {
+		paths[i] = s.Path(t)
+	}
+	return paths
+}
+
+// ASCII writes a top down view of the planned paths. The front of the drones at the start is the top of the grid.
+// Each drone is drawn with its own letter, in upper case where it starts. The estimated collisions are marked with X.
+func (s *Show) ASCII(w io.Writer, width, height int) error {
+	if width < 2 || height < 2 {
+		return fmt.Errorf("the preview needs to be at least 2x2")
+	}
+	paths := s.paths()
+	b := s.bounds(paths)
+	grid := make([][]rune, height)
+	for row := range grid {
+		grid[row] = []rune(strings.Repeat(".", width))
+	}
+	// Both axes use the same scale, so the distances are not distorted
+	scale := math.Max((b.maxX-b.minX)/float64(width-1), (b.maxY-b.minY)/float64(height-1))
+	cell := func(p Position) (int, int) {
+		col := int(math.Round((p.X - b.minX) / scale))
+		row := int(math.Round((b.maxY - p.Y) / scale))
+		return row, col
+	}
+
+	for i, path := range paths {
+		mark := rune('a' + i%26)
+		draw := func(row, col int) {
+			switch grid[row][col] {
+			case '.', mark:
+				grid[row][col] = mark
+			default:
+				// The paths cross, but not necessarily at the same time
+				grid[row][col] = '+'
+			}
+		}
+		for k := 1; k < len(path); k++ {
+			fromRow, fromCol := cell(path[k-1])
+			toRow, toCol := cell(path[k])
+			steps := int(math.Max(math.Abs(float64(toRow-fromRow)), math.Abs(float64(toCol-fromCol))))
+			for j := 0; j <= steps; j++ {
+				progress := 1.0
+				if steps > 0 {
+					progress = float64(j) / float64(steps)
+				}
+				draw(fromRow+int(math.Round(float64(toRow-fromRow)*progress)), fromCol+int(math.Round(float64(toCol-fromCol)*progress)))
+			}
+		}
+	}
+	for i, t := range s.Tracks {
+		row, col := cell(t.Start)
+		grid[row][col] = rune('A' + i%26)
+	}
+	for _, c := range s.Collisions() {
+		for i, t := range s.Tracks {
+			if t.Drone == c.First {
+				row, col := cell(paths[i][int(c.At/sampleInterval)])
+				grid[row][col] = 'X'
+			}
+		}
+	}
+
+	for _, line := range grid {
+		if _, err := fmt.Fprintln(w, string(line)); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "\n%s (%s, 1 column = %.0fcm)\n", s.Name, s.Duration(), scale); err != nil {
+		return err
+	}
+	for i, t := range s.Tracks {
+		if _, err := fmt.Fprintf(w, "  %c  %s\n", 'A'+i%26, t.Drone); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintln(w, "  +  crossing paths\n  X  collision"); err != nil {
+		return err
+	}
+	for _, c := range s.Collisions() {
+		if _, err := fmt.Fprintf(w, "Collision: %s\n", c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SVG writes a top down view of the planned paths as an SVG image. One unit in the image is one centimetre.
+func (s *Show) SVG(w io.Writer) error {
+	paths := s.paths()
+	b := s.bounds(paths)
+	width, height := b.maxX-b.minX, b.maxY-b.minY
+	// The Y axis of SVG points down, so the positions are flipped to keep the front of the drones at the top
+	point := func(p Position) (float64, float64) {
+		return p.X - b.minX, b.maxY - p.Y
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %.0f %.0f" width="%.0f" height="%.0f">`+"\n", width, height, width, height)
+	fmt.Fprintf(&sb, `  <title>%s</title>`+"\n", escape(s.Name))
+	fmt.Fprintf(&sb, `  <rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	for i, path := range paths {
+		colour := colours[i%len(colours)]
+		var points []string
+		for _, p := range path {
+			x, y := point(p)
+			if xy := fmt.Sprintf("%.1f,%.1f", x, y); len(points) == 0 || points[len(points)-1] != xy {
+				points = append(points, xy)
+			}
+		}
+		fmt.Fprintf(&sb, `  <polyline points="%s" fill="none" stroke="%s" stroke-width="3"/>`+"\n", strings.Join(points, " "), colour)
+		x, y := point(s.Tracks[i].Start)
+		fmt.Fprintf(&sb, `  <circle cx="%.1f" cy="%.1f" r="8" fill="%s"/>`+"\n", x, y, colour)
+		fmt.Fprintf(&sb, `  <text x="%.1f" y="%.1f" font-family="sans-serif" font-size="16" fill="%s">%s</text>`+"\n", x+10, y-10, colour, escape(s.Tracks[i].Drone))
+	}
+	for _, c := range s.Collisions() {
+		for i, t := range s.Tracks {
+			if t.Drone != c.First {
+				continue
+			}
+			x, y := point(paths[i][int(c.At/sampleInterval)])
+			fmt.Fprintf(&sb, `  <circle cx="%.1f" cy="%.1f" r="%.1f" fill="red" fill-opacity="0.3" stroke="red"><title>%s</title></circle>`+"\n", x, y, s.MinDistance/2, escape(c.Error()))
+		}
+	}
+	sb.WriteString("</svg>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
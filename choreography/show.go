@@ -0,0 +1,193 @@
+package choreography
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/xitonix/gophobotics/input"
+)
+
+const (
+	// MoveDuration is how long the robots keep moving after a movement command
+	MoveDuration = 500 * time.Millisecond
+	// TakeOffDuration is how long it takes for a drone to take off or land
+	TakeOffDuration = 3 * time.Second
+)
+
+// Show is a synchronised multi drone choreography
+type Show struct {
+	// Name is the name of the show
+	Name string `json:"name"`
+	// MoveDistance is the estimated distance in centimetres a drone travels with each movement command
+	MoveDistance float64 `json:"move_distance"`
+	// TurnAngle is the estimated angle in degrees a drone turns with each rotation command
+	TurnAngle float64 `json:"turn_angle"`
+	// TakeOffHeight is the estimated height in centimetres a drone hovers at after taking off
+	TakeOffHeight float64 `json:"takeoff_height"`
+	// MinDistance is the minimum distance in centimetres the drones must keep from each other
+	MinDistance float64 `json:"min_distance"`
+	// Tracks are the moves of each drone
+	Tracks []Track `json:"drones"`
+}
+
+// Track is the timeline of a single drone
+type Track struct {
+	// Drone is the name of the drone in the swarm
+	Drone string `json:"name"`
+	// Start is where the drone is placed before the show starts. The drones are facing the same direction (+Y) at the start.
+	Start Position `json:"start"`
+	// Steps are the commands of the drone in chronological order
+	Steps []Step `json:"moves"`
+}
+
+// Step is a command issued to a drone at a point in time
+type Step struct {
+	// At is the number of seconds since the beginning of the show
+	At float64 `json:"at"`
+	// Command is the name of the command (ie. TakeOff, Forward or RotateLeft)
+	Command string `json:"command"`
+
+	command input.Command
+}
+
+// Time returns when the step is due, relative to the beginning of the show
+func (s Step) Time() time.Duration {
+	return time.Duration(s.At * float64(time.Second))
+}
+
+// Load reads a show from a JSON file and validates the timeline of every drone.
+// The collisions are not checked, so that the show can still be previewed.
+func Load(path string) (*Show, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	show := &Show{
+		MoveDistance:  50,
+		TurnAngle:     45,
+		TakeOffHeight: 80,
+		MinDistance:   100,
+	}
+	if err := json.Unmarshal(data, show); err != nil {
+		return nil, fmt.Errorf("invalid show file %s: %s", path, err)
+	}
+	if err := show.validateTracks(); err != nil {
+		return nil, err
+	}
+	return show, nil
+}
+
+// Validate checks the timeline of every drone and makes sure the drones never get too close to each other
+func (s *Show) Validate() error {
+	if err := s.validateTracks(); err != nil {
+		return err
+	}
+	if collisions := s.Collisions(); len(collisions) > 0 {
+		return collisions[0]
+	}
+	return nil
+}
+
+func (s *Show) validateTracks() error {
+	if len(s.Tracks) == 0 {
+		return fmt.Errorf("the show has no drones")
+	}
+	if s.MoveDistance <= 0 || s.TurnAngle <= 0 || s.TakeOffHeight <= 0 || s.MinDistance < 0 {
+		return fmt.Errorf("the distances and the angles of the show must be positive")
+	}
+	names := make(map[string]bool)
+	for i := range s.Tracks {
+		t := &s.Tracks[i]
+		if t.Drone == "" {
+			return fmt.Errorf("the drones of the show need a name")
+		}
+		if names[t.Drone] {
+			return fmt.Errorf("duplicate drone %q in the show", t.Drone)
+		}
+		names[t.Drone] = true
+		if err := t.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Drones returns the names of the drones in the show
+func (s *Show) Drones() []string {
+	names := make([]string, 0, len(s.Tracks))
+	for _, t := range s.Tracks {
+		names = append(names, t.Drone)
+	}
+	return names
+}
+
+// Duration returns when the last command of the show finishes
+func (s *Show) Duration() time.Duration {
+	var end time.Duration
+	for _, t := range s.Tracks {
+		if len(t.Steps) == 0 {
+			continue
+		}
+		last := t.Steps[len(t.Steps)-1]
+		if finish := last.Time() + duration(last.command); finish > end {
+			end = finish
+		}
+	}
+	return end
+}
+
+func (t *Track) validate() error {
+	sort.SliceStable(t.Steps, func(i, j int) bool { return t.Steps[i].At < t.Steps[j].At })
+	var busyUntil time.Duration
+	airborne := false
+	for i := range t.Steps {
+		step := &t.Steps[i]
+		step.command = input.ParseCommand(step.Command)
+		if step.At < 0 {
+			return fmt.Errorf("%s: the moves cannot start before the show", t.Drone)
+		}
+		switch step.command {
+		case input.None, input.Exit:
+			return fmt.Errorf("%s: %q is not a valid move", t.Drone, step.Command)
+		case input.TakeOff:
+			airborne = true
+		case input.Land:
+			airborne = false
+		default:
+			if !airborne {
+				return fmt.Errorf("%s: %s at %.1fs while the drone is on the ground", t.Drone, step.command, step.At)
+			}
+			if !isMovement(step.command) {
+				return fmt.Errorf("%s: %s is not supported in a show", t.Drone, step.command)
+			}
+		}
+		if step.Time() < busyUntil {
+			return fmt.Errorf("%s: %s at %.1fs starts before the previous move has finished", t.Drone, step.command, step.At)
+		}
+		busyUntil = step.Time() + duration(step.command)
+	}
+	if airborne {
+		return fmt.Errorf("%s: the drone must land at the end of the show", t.Drone)
+	}
+	return nil
+}
+
+func isMovement(cmd input.Command) bool {
+	switch cmd {
+	case input.Forward, input.Backward, input.Left, input.Right, input.Up, input.Down, input.RotateLeft, input.RotateRight:
+		return true
+	default:
+		return false
+	}
+}
+
+// duration returns how long the command keeps the drone busy
+func duration(cmd input.Command) time.Duration {
+	if cmd.IsLandOrTakeoff() {
+		return TakeOffDuration
+	}
+	return MoveDuration
+}
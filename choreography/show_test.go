@@ -0,0 +1,95 @@
+package choreography
+
+import (
+	"strings"
+	"testing"
+)
+
+func steps(moves ...interface{}) []Step {
+	result := make([]Step, 0, len(moves)/2)
+	for i := 0; i < len(moves); i += 2 {
+		result = append(result, Step{At: moves[i].(float64), Command: moves[i+1].(string)})
+	}
+	return result
+}
+
+func TestTrackValidate(t *testing.T) {
+	testCases := []struct {
+		title string
+		steps []Step
+		err   string
+	}{
+		{
+			title: "no moves",
+		},
+		{
+			title: "take off, move and land",
+			steps: steps(0.0, "takeoff", 3.0, "forward", 3.5, "RotateRight", 4.0, "land"),
+		},
+		{
+			title: "out of order moves are sorted",
+			steps: steps(4.0, "land", 0.0, "takeoff", 3.0, "forward"),
+		},
+		{
+			title: "move before the show",
+			steps: steps(-1.0, "takeoff", 3.0, "land"),
+			err:   "cannot start before the show",
+		},
+		{
+			title: "unknown command",
+			steps: steps(0.0, "takeoff", 3.0, "somersault", 4.0, "land"),
+			err:   `"somersault" is not a valid move`,
+		},
+		{
+			title: "exit",
+			steps: steps(0.0, "takeoff", 3.0, "exit"),
+			err:   `"exit" is not a valid move`,
+		},
+		{
+			title: "move on the ground",
+			steps: steps(0.0, "forward"),
+			err:   "while the drone is on the ground",
+		},
+		{
+			title: "flip",
+			steps: steps(0.0, "takeoff", 3.0, "FrontFlip", 4.0, "land"),
+			err:   "is not supported in a show",
+		},
+		{
+			title: "overlapping moves",
+			steps: steps(0.0, "takeoff", 3.0, "forward", 3.2, "left", 4.0, "land"),
+			err:   "starts before the previous move has finished",
+		},
+		{
+			title: "move during the take off",
+			steps: steps(0.0, "takeoff", 1.0, "forward", 4.0, "land"),
+			err:   "starts before the previous move has finished",
+		},
+		{
+			title: "no landing",
+			steps: steps(0.0, "takeoff", 3.0, "forward"),
+			err:   "must land at the end of the show",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			track := Track{Drone: "alpha", Steps: tc.steps}
+			err := track.validate()
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("Expected the track to be valid, got %s", err)
+				}
+				for i := 1; i < len(track.Steps); i++ {
+					if track.Steps[i].At < track.Steps[i-1].At {
+						t.Errorf("Expected the moves to be in chronological order, got %v", track.Steps)
+					}
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
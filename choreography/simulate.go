@@ -0,0 +1,127 @@
+package choreography
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/xitonix/gophobotics/input"
+)
+
+// sampleInterval is the resolution of the collision detection
+const sampleInterval = 100 * time.Millisecond
+
+// Position is an estimated position in centimetres. X points to the right and Y to the front of the drones at the start.
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// Distance returns the distance between two positions in centimetres
+func (p Position) Distance(other Position) float64 {
+	return math.Sqrt(math.Pow(p.X-other.X, 2) + math.Pow(p.Y-other.Y, 2) + math.Pow(p.Z-other.Z, 2))
+}
+
+// Collision is reported when two drones are estimated to get closer than the minimum distance
+type Collision struct {
+	// At is the time since the beginning of the show
+	At time.Duration
+	// First and Second are the names of the drones
+	First, Second string
+	// Distance is the estimated distance between the drones in centimetres
+	Distance float64
+}
+
+func (c Collision) Error() string {
+	return fmt.Sprintf("%s and %s are only %.0fcm apart at %s", c.First, c.Second, c.Distance, c.At)
+}
+
+// Collisions returns the first moment each pair of drones is estimated to get closer than the minimum distance
+func (s *Show) Collisions() []Collision {
+	paths := make([][]Position, len(s.Tracks))
+	for i, t := range s.Tracks {
+		paths[i] = s.Path(t)
+	}
+	var collisions []Collision
+	for i := 0; i < len(paths); i++ {
+		for j := i + 1; j < len(paths); j++ {
+			// All the paths cover the whole show, so they have the same number of samples
+			for k := range paths[i] {
+				if d := paths[i][k].Distance(paths[j][k]); d < s.MinDistance {
+					collisions = append(collisions, Collision{
+						At:       time.Duration(k) * sampleInterval,
+						First:    s.Tracks[i].Drone,
+						Second:   s.Tracks[j].Drone,
+						Distance: d,
+					})
+					break
+				}
+			}
+		}
+	}
+	return collisions
+}
+
+// Path returns the estimated positions of the drone from the beginning to the end of the show, sampled every 100ms
+func (s *Show) Path(t Track) []Position {
+	samples := int(s.Duration()/sampleInterval) + 1
+	path := make([]Position, 0, samples)
+	from, to := t.Start, t.Start
+	heading := 0.0
+	next := 0
+	var started, finish time.Duration
+	for k := 0; k < samples; k++ {
+		now := time.Duration(k) * sampleInterval
+		for next < len(t.Steps) && t.Steps[next].Time() <= now {
+			step := t.Steps[next]
+			from = to
+			to, heading = s.move(from, heading, step.command)
+			started, finish = step.Time(), step.Time()+duration(step.command)
+			next++
+		}
+		if now >= finish {
+			path = append(path, to)
+			continue
+		}
+		progress := float64(now-started) / float64(finish-started)
+		path = append(path, Position{
+			X: from.X + (to.X-from.X)*progress,
+			Y: from.Y + (to.Y-from.Y)*progress,
+			Z: from.Z + (to.Z-from.Z)*progress,
+		})
+	}
+	return path
+}
+
+// move estimates where the command takes the drone and which way it will be facing
+func (s *Show) move(p Position, heading float64, cmd input.Command) (Position, float64) {
+	// The heading is measured clockwise from +Y
+	rad := heading * math.Pi / 180
+	forward := Position{X: math.Sin(rad), Y: math.Cos(rad)}
+	right := Position{X: math.Cos(rad), Y: -math.Sin(rad)}
+	d := s.MoveDistance
+	switch cmd {
+	case input.TakeOff:
+		p.Z = s.TakeOffHeight
+	case input.Land:
+		p.Z = 0
+	case input.Forward:
+		p.X, p.Y = p.X+forward.X*d, p.Y+forward.Y*d
+	case input.Backward:
+		p.X, p.Y = p.X-forward.X*d, p.Y-forward.Y*d
+	case input.Right:
+		p.X, p.Y = p.X+right.X*d, p.Y+right.Y*d
+	case input.Left:
+		p.X, p.Y = p.X-right.X*d, p.Y-right.Y*d
+	case input.Up:
+		p.Z += d
+	case input.Down:
+		p.Z = math.Max(p.Z-d, 0)
+	case input.RotateRight:
+		heading += s.TurnAngle
+	case input.RotateLeft:
+		heading -= s.TurnAngle
+	}
+	return p, heading
+}
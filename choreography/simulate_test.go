@@ -0,0 +1,136 @@
+package choreography
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func newTestShow(t *testing.T, tracks ...Track) *Show {
+	t.Helper()
+	show := &Show{
+		MoveDistance:  50,
+		TurnAngle:     90,
+		TakeOffHeight: 80,
+		MinDistance:   100,
+		Tracks:        tracks,
+	}
+	if err := show.validateTracks(); err != nil {
+		t.Fatalf("Invalid show: %s", err)
+	}
+	return show
+}
+
+func near(a, b Position) bool {
+	return a.Distance(b) < 0.001
+}
+
+func TestShowPath(t *testing.T) {
+	show := newTestShow(t, Track{
+		Drone: "alpha",
+		Start: Position{X: 10, Y: 20},
+		Steps: steps(0.0, "takeoff", 3.0, "forward", 3.5, "RotateRight", 4.0, "forward", 4.5, "up", 5.0, "land"),
+	})
+	path := show.Path(show.Tracks[0])
+
+	if expected := int(show.Duration()/sampleInterval) + 1; len(path) != expected {
+		t.Fatalf("Expected %d samples, got %d", expected, len(path))
+	}
+	at := func(d time.Duration) Position {
+		return path[int(d/sampleInterval)]
+	}
+	expectations := []struct {
+		at       time.Duration
+		position Position
+	}{
+		{0, Position{X: 10, Y: 20}},
+		// Half way through the take off
+		{1500 * time.Millisecond, Position{X: 10, Y: 20, Z: 40}},
+		{3 * time.Second, Position{X: 10, Y: 20, Z: 80}},
+		{3500 * time.Millisecond, Position{X: 10, Y: 70, Z: 80}},
+		// Facing right after the rotation, so forward moves along X
+		{4500 * time.Millisecond, Position{X: 60, Y: 70, Z: 80}},
+		{5 * time.Second, Position{X: 60, Y: 70, Z: 130}},
+		{8 * time.Second, Position{X: 60, Y: 70}},
+	}
+	for _, e := range expectations {
+		if actual := at(e.at); !near(actual, e.position) {
+			t.Errorf("Expected the drone to be at %+v after %s, got %+v", e.position, e.at, actual)
+		}
+	}
+}
+
+func TestShowPathCoversTheWholeShow(t *testing.T) {
+	show := newTestShow(t,
+		Track{Drone: "alpha", Steps: steps(0.0, "takeoff", 3.0, "land")},
+		Track{Drone: "bravo", Start: Position{X: 200}, Steps: steps(5.0, "takeoff", 8.0, "land")},
+	)
+	short, long := show.Path(show.Tracks[0]), show.Path(show.Tracks[1])
+	if len(short) != len(long) {
+		t.Fatalf("Expected the paths to have the same number of samples, got %d and %d", len(short), len(long))
+	}
+	if last := short[len(short)-1]; !near(last, Position{}) {
+		t.Errorf("Expected the first drone to stay where it landed, got %+v", last)
+	}
+}
+
+func TestShowCollisions(t *testing.T) {
+	testCases := []struct {
+		title      string
+		tracks     []Track
+		collisions []Collision
+	}{
+		{
+			title: "far apart",
+			tracks: []Track{
+				{Drone: "alpha", Steps: steps(0.0, "takeoff", 3.0, "land")},
+				{Drone: "bravo", Start: Position{X: 150}, Steps: steps(0.0, "takeoff", 3.0, "land")},
+			},
+		},
+		{
+			title: "too close at the start",
+			tracks: []Track{
+				{Drone: "alpha", Steps: steps(0.0, "takeoff", 3.0, "land")},
+				{Drone: "bravo", Start: Position{X: 60}, Steps: steps(0.0, "takeoff", 3.0, "land")},
+			},
+			collisions: []Collision{{At: 0, First: "alpha", Second: "bravo", Distance: 60}},
+		},
+		{
+			title: "moving towards each other",
+			tracks: []Track{
+				{Drone: "alpha", Steps: steps(0.0, "takeoff", 3.0, "right", 4.0, "land")},
+				{Drone: "bravo", Start: Position{X: 150}, Steps: steps(0.0, "takeoff", 3.0, "left", 4.0, "land")},
+			},
+			// 150cm apart, closing at 200cm/s until they are 50cm apart
+			collisions: []Collision{{At: 3300 * time.Millisecond, First: "alpha", Second: "bravo", Distance: 90}},
+		},
+		{
+			title: "only the first moment of each pair",
+			tracks: []Track{
+				{Drone: "alpha", Steps: steps(0.0, "takeoff", 3.0, "land")},
+				{Drone: "bravo", Start: Position{X: 50}, Steps: steps(0.0, "takeoff", 3.0, "land")},
+				{Drone: "charlie", Start: Position{X: 300}, Steps: steps(0.0, "takeoff", 3.0, "land")},
+			},
+			collisions: []Collision{{At: 0, First: "alpha", Second: "bravo", Distance: 50}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			show := newTestShow(t, tc.tracks...)
+			collisions := show.Collisions()
+			if len(collisions) != len(tc.collisions) {
+				t.Fatalf("Expected %d collisions, got %v", len(tc.collisions), collisions)
+			}
+			for i, expected := range tc.collisions {
+				actual := collisions[i]
+				if actual.At != expected.At || actual.First != expected.First || actual.Second != expected.Second || math.Abs(actual.Distance-expected.Distance) > 0.001 {
+					t.Errorf("Expected %+v, got %+v", expected, actual)
+				}
+			}
+			if err := show.Validate(); (err == nil) != (len(tc.collisions) == 0) {
+				t.Errorf("Expected Validate to report the collisions, got %v", err)
+			}
+		})
+	}
+}
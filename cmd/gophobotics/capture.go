@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
+	"github.com/xitonix/gophobotics/robot"
+	"github.com/xitonix/gophobotics/vision"
+)
+
+// angularRobot is a robot which turns by a fixed angle with each rotation command
+type angularRobot interface {
+	RotationAngle() int
+}
+
+// captureOptions are the flags of the capture command
+type captureOptions struct {
+	mode     string
+	dir      string
+	settle   time.Duration
+	interval time.Duration
+	count    int
+	file     string
+	hold     time.Duration
+}
+
+func captureFlags(flags *pflag.FlagSet) runner {
+	var options captureOptions
+	flags.StringVar(&options.mode, "mode", "panorama", "The camera routine (panorama or timelapse)")
+	flags.StringVar(&options.dir, "dir", "photos", "The directory the photos and their metadata are saved to")
+	flags.DurationVar(&options.settle, "settle", 3*time.Second, "How long the drone is given to steady itself before a photo is taken")
+	flags.DurationVar(&options.interval, "interval", 5*time.Second, "The time between the photos of a timelapse")
+	flags.IntVar(&options.count, "count", 0, "The number of photos of a timelapse. Zero keeps taking photos until the routine is cancelled")
+	flags.StringVar(&options.file, "file", "", "Takes the photos from a recorded H.264 file instead of the live feed of the drone")
+	flags.DurationVar(&options.hold, "hold", 3*time.Second, "How long the pilot keeps the control after pressing a key")
+	return func(cfg config.Config) error {
+		return runCapture(cfg, options)
+	}
+}
+
+func runCapture(cfg config.Config, options captureOptions) error {
+	mode, err := vision.ParseCaptureMode(options.mode)
+	if err != nil {
+		return err
+	}
+	settings := vision.DefaultCaptureOptions(mode, options.dir)
+	settings.Settle = options.settle
+	settings.Interval = options.interval
+	settings.Count = options.count
+
+	var decoder *vision.Decoder
+	var routine *vision.Capture
+	wrap := func(pilot input.Runner, logger *logging.Logger, bus *event.Bus) (input.Runner, error) {
+		var err error
+		decoder, err = openFrames(cfg, options.file, logger)
+		if err != nil {
+			return nil, err
+		}
+		if routine, err = vision.NewCapture(decoder.Frames(), settings, logger); err != nil {
+			return nil, err
+		}
+		routine.SetEventBus(bus)
+
+		// Any key pressed by the pilot cancels the routine and wins over its rotations for the hold period
+		mux := input.NewMultiplexer(options.hold, logger)
+		mux.Add("pilot", 1, pilot)
+		mux.Add("capture", 0, routine)
+		return &sources{Multiplexer: mux, runners: []input.Runner{pilot, routine}}, nil
+	}
+
+	err = flyFrom(cfg, wrap, func(robo robot.Robot, logger *logging.Logger) error {
+		if mode == vision.Panorama {
+			// The panorama stops every rotation, so the rotations must turn the drone by a known angle
+			turning, ok := robo.(angularRobot)
+			if !ok {
+				return fmt.Errorf("the %s robot does not turn by a fixed angle, use the tello-sdk robot to take a panorama", cfg.Robot)
+			}
+			if err := routine.SetTurn(float64(turning.RotationAngle())); err != nil {
+				return err
+			}
+		}
+		if reporter, ok := robo.(stateRobot); ok {
+			routine.SetTelemetry(func() vision.Telemetry {
+				state := reporter.State()
+				return vision.Telemetry{
+					Airborne: state.Airborne,
+					Height:   int(state.Height),
+					Speed:    int(state.Speed),
+					Battery:  int(state.Battery),
+					Wifi:     int(state.WifiStrength),
+				}
+			})
+		}
+		return streamFrames(cfg, robo, options.file, decoder)
+	})
+	if decoder != nil {
+		_ = decoder.Close()
+	}
+	return err
+}
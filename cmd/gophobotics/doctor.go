@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
+	"github.com/xitonix/gophobotics/doctor"
+)
+
+const (
+	// defaultMinBattery is the battery percentage required to take off if no profile has been selected
+	defaultMinBattery = 20
+	// minWifiStrength is the Wi-Fi signal strength below which a warning is reported
+	minWifiStrength = 40
+)
+
+// doctorFlags registers no flags, the checks are set up by the configuration
+func doctorFlags(*pflag.FlagSet) runner {
+	return runDoctor
+}
+
+func runDoctor(cfg config.Config) error {
+	var drone doctor.Drone
+	if cfg.Robot == "tello" {
+		drone = doctor.Tello(cfg.Drone.Address, cfg.Drone.CommandPort, cfg.Drone.LocalPort)
+		defer drone.Close()
+	}
+	return diagnose(os.Stdout, cfg, doctor.LocalSystem(), drone)
+}
+
+// diagnose runs the preflight checks on the system and against the drone, if there is one, and writes the report.
+// It fails if the drone is not allowed to take off.
+func diagnose(w io.Writer, cfg config.Config, system doctor.System, drone doctor.Drone) error {
+	minBattery := int8(defaultMinBattery)
+	if cfg.ActiveProfile != nil {
+		minBattery = cfg.ActiveProfile.Limits().MinTakeOffBattery
+	}
+
+	// The browser viewer decodes the video using ffmpeg instead of a video player
+	player := cfg.Video.Player
+	if cfg.Video.Viewer == "browser" {
+		player = cfg.Video.FFmpeg
+	}
+	checks := []doctor.Check{
+		doctor.Terminal(system),
+		doctor.Player(system, player),
+		doctor.Port(system, "Command", cfg.Drone.LocalPort),
+		doctor.Port(system, "Video", cfg.Drone.VideoPort),
+	}
+	if drone != nil {
+		checks = append(checks, doctor.DroneChecks(drone, minBattery, minWifiStrength)...)
+	}
+
+	fmt.Fprintln(w, "Running the preflight checks...")
+	report := doctor.Run(checks...)
+	printReport(w, report)
+
+	if !report.Ready() {
+		return errors.New("fix the failed checks before taking off")
+	}
+	fmt.Fprintln(w, "Ready for takeoff")
+	return nil
+}
+
+func printReport(w io.Writer, report doctor.Report) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, result := range report {
+		fmt.Fprintf(tw, "[%s]\t%s\t%s\n", result.Status, result.Name, result.Message)
+	}
+	_ = tw.Flush()
+}
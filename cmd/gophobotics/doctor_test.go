@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xitonix/gophobotics/config"
+	"github.com/xitonix/gophobotics/doctor"
+	"github.com/xitonix/gophobotics/doctor/doctortest"
+)
+
+func TestDiagnose(t *testing.T) {
+	healthy := &doctortest.Drone{Reported: doctor.DroneInfo{Battery: 90, WifiStrength: 80, Version: "01.04.92.01"}}
+	testCases := []struct {
+		title    string
+		system   doctortest.System
+		drone    doctor.Drone
+		expected []string
+		fails    bool
+	}{
+		{
+			title:    "ready",
+			drone:    healthy,
+			expected: []string{"[PASS]  Terminal", "[PASS]  Video Player  /usr/bin/ffmpeg", "[PASS]  Battery", "Ready for takeoff"},
+		},
+		{
+			title:    "no drone",
+			expected: []string{"[PASS]  Command Port", "Ready for takeoff"},
+		},
+		{
+			title:    "busy video port",
+			system:   doctortest.System{Busy: map[int]bool{6038: true}},
+			drone:    healthy,
+			expected: []string{"[FAIL]  Video Port"},
+			fails:    true,
+		},
+		{
+			title:    "flat battery",
+			drone:    &doctortest.Drone{Reported: doctor.DroneInfo{Battery: 5, WifiStrength: 80, Version: "01.04.92.01"}},
+			expected: []string{"[FAIL]  Battery"},
+			fails:    true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			var out bytes.Buffer
+			err := diagnose(&out, config.Default(), tc.system, tc.drone)
+			if (err != nil) != tc.fails {
+				t.Errorf("Expected failure to be %v, got %v", tc.fails, err)
+			}
+			for _, line := range tc.expected {
+				if !strings.Contains(out.String(), line) {
+					t.Errorf("Expected the report to contain %q, got\n%s", line, out.String())
+				}
+			}
+			if tc.drone == nil && strings.Contains(out.String(), "Battery") {
+				t.Errorf("Expected no drone checks, got\n%s", out.String())
+			}
+		})
+	}
+}
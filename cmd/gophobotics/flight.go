@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/fake"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
+	"github.com/xitonix/gophobotics/robot"
+)
+
+// flyOptions are the flags of the fly command
+type flyOptions struct {
+	fake bool
+}
+
+func flyFlags(flags *pflag.FlagSet) runner {
+	var options flyOptions
+	flags.BoolVar(&options.fake, "fake", false, "Flies a local fake drone in place of the real one (tello-sdk only)")
+	return func(cfg config.Config) error {
+		return runFly(cfg, options)
+	}
+}
+
+// echoFlags only prints the commands, so there is no drone to fake
+func echoFlags(*pflag.FlagSet) runner {
+	return func(cfg config.Config) error {
+		return runFly(cfg, flyOptions{})
+	}
+}
+
+func runFly(cfg config.Config, options flyOptions) error {
+	if options.fake {
+		if cfg.Robot != "tello-sdk" {
+			return fmt.Errorf("the fake drone is only available for the tello-sdk robot")
+		}
+		drone, err := fake.NewSDK("127.0.0.1:0", cfg.Drone.StatePort)
+		if err != nil {
+			return err
+		}
+		defer drone.Close()
+		cfg.Drone.Address = "127.0.0.1"
+		cfg.Drone.CommandPort = drone.Port()
+		fmt.Printf("Fake drone is listening on port %d\n", drone.Port())
+	}
+	return fly(cfg, nil)
+}
+
+// fly wires the configured source to the configured robot and blocks until the source is closed.
+// The prepare function, if provided, is called once the robot has been created and before it gets connected.
+func fly(cfg config.Config, prepare func(robo robot.Robot, logger *logging.Logger) error) error {
+	return flyFrom(cfg, nil, prepare)
+}
+
+// flyFrom works like fly, but the wrap function, if provided, can replace the configured source (ie. to merge it with other sources)
+func flyFrom(cfg config.Config, wrap func(source input.Runner, logger *logging.Logger, bus *event.Bus) (input.Runner, error), prepare func(robo robot.Robot, logger *logging.Logger) error) error {
+	logger, closer, err := cfg.OpenLogger()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	if cfg.ActiveProfile != nil {
+		fmt.Printf("Profile: %s\n", cfg.ActiveProfile)
+	}
+
+	bus := event.NewBus()
+	source, err := input.New(cfg.Source, cfg.SourceOptions(logger, bus))
+	if err != nil {
+		return err
+	}
+	if wrap != nil {
+		if source, err = wrap(source, logger, bus); err != nil {
+			return err
+		}
+	}
+	pipeline := input.NewPipeline(source, logger, cfg.Middlewares()...)
+
+	robo, err := robot.New(cfg.Robot, cfg.RobotOptions(logger, bus))
+	if err != nil {
+		return err
+	}
+	if prepare != nil {
+		if err := prepare(robo, logger); err != nil {
+			return err
+		}
+	}
+
+	alerts := bus.Subscribe(event.BatteryLow, event.Disconnected, event.Failsafe)
+	defer alerts.Cancel()
+	go func() {
+		for e := range alerts.Events() {
+			fmt.Printf("Alert: %s\n", e)
+		}
+	}()
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for err := range robo.Errors() {
+			if errors.Is(err, robot.ErrOverLimit) {
+				fmt.Printf("Err: %s. Try the opposite direction first\n", err)
+				continue
+			}
+			fmt.Printf("Err: %s\n", err)
+		}
+	}()
+
+	var connectErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		connectErr = robo.Connect(pipeline)
+	}()
+
+	go func() {
+		_ = pipeline.Start()
+	}()
+
+	if err := source.Start(); err != nil {
+		return err
+	}
+
+	wg.Wait()
+	return connectErr
+}
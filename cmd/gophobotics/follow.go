@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
+	"github.com/xitonix/gophobotics/robot"
+	"github.com/xitonix/gophobotics/vision"
+)
+
+// followOptions are the flags of the follow command
+type followOptions struct {
+	colour     string
+	targetSize float64
+	file       string
+	hold       time.Duration
+}
+
+func followFlags(flags *pflag.FlagSet) runner {
+	var options followOptions
+	flags.StringVar(&options.colour, "colour", "orange", fmt.Sprintf("The colour of the object to follow (%s or a hue range such as 100-140)", strings.Join(vision.Colours(), ", ")))
+	flags.Float64Var(&options.targetSize, "target-size", 0.05, "The fraction of the picture the object should cover, which sets the distance to keep")
+	flags.StringVar(&options.file, "file", "", "Follows the object in a recorded H.264 file instead of the live feed of the drone")
+	flags.DurationVar(&options.hold, "hold", 3*time.Second, "How long the pilot keeps the control after pressing a key")
+	return func(cfg config.Config) error {
+		return runFollow(cfg, options)
+	}
+}
+
+func runFollow(cfg config.Config, options followOptions) error {
+	colour, err := vision.ParseColour(options.colour)
+	if err != nil {
+		return err
+	}
+	settings := vision.DefaultFollowOptions(colour)
+	settings.TargetSize = options.targetSize
+
+	var decoder *vision.Decoder
+	var follower *vision.Follower
+	wrap := func(pilot input.Runner, logger *logging.Logger, bus *event.Bus) (input.Runner, error) {
+		var err error
+		decoder, err = openFrames(cfg, options.file, logger)
+		if err != nil {
+			return nil, err
+		}
+		follower = vision.NewFollower(decoder.Frames(), settings, logger)
+		follower.SetEventBus(bus)
+
+		// Any key pressed by the pilot wins over the follower for the hold period
+		mux := input.NewMultiplexer(options.hold, logger)
+		mux.Add("pilot", 1, pilot)
+		mux.Add("follow", 0, follower)
+		return &sources{Multiplexer: mux, runners: []input.Runner{pilot, follower}}, nil
+	}
+
+	err = flyFrom(cfg, wrap, func(robo robot.Robot, logger *logging.Logger) error {
+		return streamFrames(cfg, robo, options.file, decoder)
+	})
+	if decoder != nil {
+		_ = decoder.Close()
+	}
+	return err
+}
+
+// openFrames starts decoding the recorded file in real time, or the live feed of the drone if no file has been specified
+func openFrames(cfg config.Config, file string, logger *logging.Logger) (*vision.Decoder, error) {
+	options := cfg.DecoderOptions()
+	if file != "" {
+		options.Realtime = true
+		return vision.DecodeFile(file, options, logger)
+	}
+	return vision.NewDecoder(options, logger)
+}
+
+// streamFrames feeds the live video of the robot into the decoder. Nothing needs to be done for a recorded file.
+func streamFrames(cfg config.Config, robo robot.Robot, file string, decoder *vision.Decoder) error {
+	if file != "" {
+		return nil
+	}
+	streamer, ok := robo.(videoRobot)
+	if !ok {
+		return fmt.Errorf("the %s robot does not support video", cfg.Robot)
+	}
+	meter(robo, decoder)
+	return streamer.Video(decoder)
+}
+
+// sources runs several sources merged by a multiplexer
+type sources struct {
+	*input.Multiplexer
+	runners []input.Runner
+}
+
+// Start starts all the sources and blocks until the multiplexer is closed, or one of the sources fails
+func (s *sources) Start() error {
+	failures := make(chan error, len(s.runners)+1)
+	for _, r := range s.runners {
+		go func(r input.Runner) {
+			if err := r.Start(); err != nil {
+				failures <- err
+			}
+		}(r)
+	}
+	go func() {
+		failures <- s.Multiplexer.Start()
+	}()
+	return <-failures
+}
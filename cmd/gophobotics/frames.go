@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"image/jpeg"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+
+	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
+	"github.com/xitonix/gophobotics/logging"
+	"github.com/xitonix/gophobotics/robot"
+	"github.com/xitonix/gophobotics/vision"
+)
+
+// framesOptions are the flags of the frames command
+type framesOptions struct {
+	file     string
+	dir      string
+	every    int
+	realtime bool
+}
+
+func framesFlags(flags *pflag.FlagSet) runner {
+	var options framesOptions
+	flags.StringVar(&options.file, "file", "", "Decodes a recorded H.264 file instead of the live feed of the drone")
+	flags.StringVar(&options.dir, "dir", "", "Saves the frames as JPEG images into the specified directory")
+	flags.IntVar(&options.every, "every", 10, "Saves every nth frame only")
+	flags.BoolVar(&options.realtime, "realtime", false, "Decodes the recorded file at its native rate")
+	return func(cfg config.Config) error {
+		return runFrames(cfg, options)
+	}
+}
+
+func runFrames(cfg config.Config, options framesOptions) error {
+	if options.every < 1 {
+		return fmt.Errorf("invalid frame interval %d", options.every)
+	}
+	if options.dir != "" {
+		if err := os.MkdirAll(options.dir, 0755); err != nil {
+			return err
+		}
+	}
+	decoding := cfg.DecoderOptions()
+	decoding.Realtime = options.realtime
+
+	if options.file == "" {
+		var decoder *vision.Decoder
+		var wg sync.WaitGroup
+		err := fly(cfg, func(robo robot.Robot, logger *logging.Logger) error {
+			streamer, ok := robo.(videoRobot)
+			if !ok {
+				return fmt.Errorf("the %s robot does not support video", cfg.Robot)
+			}
+			var err error
+			if decoder, err = vision.NewDecoder(decoding, logger); err != nil {
+				return err
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				saveFrames(decoder.Frames(), options, logger)
+			}()
+			meter(robo, decoder)
+			return streamer.Video(decoder)
+		})
+		if decoder != nil {
+			_ = decoder.Close()
+			wg.Wait()
+			printFrameStats(decoder.Stats())
+		}
+		return err
+	}
+
+	logger, closer, err := cfg.OpenLogger()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	decoder, err := vision.DecodeFile(options.file, decoding, logger)
+	if err != nil {
+		return err
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+	go func() {
+		if _, ok := <-signals; ok {
+			_ = decoder.Close()
+		}
+	}()
+	saveFrames(decoder.Frames(), options, logger)
+	err = decoder.Wait()
+	printFrameStats(decoder.Stats())
+	return err
+}
+
+// saveFrames reads the frames until the decoder stops and saves every nth frame if an output directory has been specified
+func saveFrames(frames <-chan vision.Frame, options framesOptions, logger *logging.Logger) {
+	for frame := range frames {
+		if options.dir == "" || frame.Seq%uint64(options.every) != 0 {
+			continue
+		}
+		path := filepath.Join(options.dir, fmt.Sprintf("frame-%06d.jpg", frame.Seq))
+		if err := saveJPEG(path, frame); err != nil {
+			logger.Errorf("Video: failed to save %s: %s", path, err)
+		}
+	}
+}
+
+func saveJPEG(path string, frame vision.Frame) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(f, frame.Image, nil); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func printFrameStats(stats vision.Stats) {
+	fmt.Printf("%d frames decoded, %d dropped\n", stats.Decoded, stats.Dropped)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/robot"
+)
+
+// command is a subcommand of the gophobotics program
+type command struct {
+	name        string
+	description string
+	// defaults adjusts the default configuration of the command
+	defaults func(cfg *config.Config)
+	// flags registers the flags which are specific to the command and returns the function which runs it with their parsed values
+	flags func(flags *pflag.FlagSet) runner
+}
+
+// runner runs a command using the loaded configuration
+type runner func(cfg config.Config) error
+
+var commands = []command{
+	{
+		name:        "fly",
+		description: "Flies the robot using the input source",
+		flags:       flyFlags,
+	},
+	{
+		name:        "echo",
+		description: "Prints the commands of the input source without flying anything",
+		defaults:    func(cfg *config.Config) { cfg.Robot = "echo" },
+		flags:       echoFlags,
+	},
+	{
+		name:        "video",
+		description: "Flies the robot and plays its video feed using the video player",
+		flags:       videoFlags,
+	},
+	{
+		name:        "record",
+		description: "Flies the robot and records its video feed with the telemetry drawn onto it",
+		flags:       recordFlags,
+	},
+	{
+		name:        "frames",
+		description: "Decodes the video feed or a recorded file into frames",
+		flags:       framesFlags,
+	},
+	{
+		name:        "follow",
+		description: "Follows an object of a specific colour using the video feed",
+		flags:       followFlags,
+	},
+	{
+		name:        "markers",
+		description: "Triggers commands when the video feed shows a printed marker",
+		flags:       markersFlags,
+	},
+	{
+		name:        "capture",
+		description: "Takes a panorama or a timelapse using the video feed",
+		flags:       captureFlags,
+	},
+	{
+		name:        "swarm",
+		description: "Flies several drones at the same time using the input source",
+		defaults:    func(cfg *config.Config) { cfg.Robot = "swarm" },
+		flags:       swarmFlags,
+	},
+	{
+		name:        "show",
+		description: "Flies or previews a choreography for several drones",
+		defaults:    func(cfg *config.Config) { cfg.Robot = "swarm" },
+		flags:       showFlags,
+	},
+	{
+		name:        "pad",
+		description: "Flies a Tello EDU drone over a mission pad and optionally jumps to another one",
+		defaults: func(cfg *config.Config) {
+			cfg.Robot = "tello-sdk"
+			cfg.MissionPads.Enabled = true
+		},
+		flags: padFlags,
+	},
+	{
+		name:        "doctor",
+		description: "Checks the environment and the drone before taking off",
+		flags:       doctorFlags,
+	},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage()
+		return
+	}
+
+	cmd, ok := find(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		printUsage()
+		os.Exit(2)
+	}
+
+	defaults := config.Default()
+	if cmd.defaults != nil {
+		cmd.defaults(&defaults)
+	}
+	flags := pflag.NewFlagSet(cmd.name, pflag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s\n\nUsage: gophobotics %s [flags]\n\n", cmd.description, cmd.name)
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		printRegistries()
+	}
+	config.RegisterFlags(flags, defaults)
+	run := cmd.flags(flags)
+	_ = flags.Parse(os.Args[2:])
+
+	cfg, err := config.Load(flags, defaults)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %s\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %s\n", cmd.name, err)
+		os.Exit(1)
+	}
+}
+
+func find(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: gophobotics <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 3, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s\t%s\n", cmd.name, cmd.description)
+	}
+	_ = w.Flush()
+	fmt.Fprintln(os.Stderr)
+	printRegistries()
+	fmt.Fprintln(os.Stderr, "Run 'gophobotics <command> --help' for the flags of a command.")
+}
+
+// printRegistries lists the sources and the robots which can be selected using the --source and --robot flags
+func printRegistries() {
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Sources (--source):")
+	for _, s := range input.Registered() {
+		fmt.Fprintf(w, "  %s\t%s\n", s.Name, s.Description)
+	}
+	fmt.Fprintln(w, "\nRobots (--robot):")
+	for _, r := range robot.Registered() {
+		fmt.Fprintf(w, "  %s\t%s\n", r.Name, r.Description)
+	}
+	_ = w.Flush()
+	fmt.Fprintln(os.Stderr)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
+	"github.com/xitonix/gophobotics/robot"
+	"github.com/xitonix/gophobotics/vision"
+)
+
+// markersOptions are the flags of the markers command
+type markersOptions struct {
+	file   string
+	hold   time.Duration
+	print  int
+	output string
+	size   float64
+}
+
+func markersFlags(flags *pflag.FlagSet) runner {
+	var options markersOptions
+	flags.StringVar(&options.file, "file", "", "Looks for the markers in a recorded H.264 file instead of the live feed of the drone")
+	flags.DurationVar(&options.hold, "hold", 3*time.Second, "How long the pilot keeps the control after pressing a key")
+	flags.IntVar(&options.print, "print", -1, fmt.Sprintf("Writes the printable marker with the specified ID (0-%d) as SVG instead of flying", vision.MaxMarkerID))
+	flags.StringVar(&options.output, "output", "", "The file the printable marker is written to. It's written to the standard output by default")
+	flags.Float64Var(&options.size, "size", 150, "The width of the printable marker in millimetres")
+	return func(cfg config.Config) error {
+		return runMarkers(cfg, options)
+	}
+}
+
+func runMarkers(cfg config.Config, options markersOptions) error {
+	if options.print >= 0 {
+		return printMarker(options.print, options.output, options.size)
+	}
+	actions, err := cfg.MarkerActions()
+	if err != nil {
+		return err
+	}
+	settings := vision.DefaultMarkerOptions(actions)
+
+	var decoder *vision.Decoder
+	wrap := func(pilot input.Runner, logger *logging.Logger, bus *event.Bus) (input.Runner, error) {
+		var err error
+		decoder, err = openFrames(cfg, options.file, logger)
+		if err != nil {
+			return nil, err
+		}
+		markers := vision.NewMarkerSource(decoder.Frames(), settings, logger)
+		markers.SetEventBus(bus)
+
+		// Any key pressed by the pilot wins over the markers for the hold period
+		mux := input.NewMultiplexer(options.hold, logger)
+		mux.Add("pilot", 1, pilot)
+		mux.Add("markers", 0, markers)
+		return &sources{Multiplexer: mux, runners: []input.Runner{pilot, markers}}, nil
+	}
+
+	err = flyFrom(cfg, wrap, func(robo robot.Robot, logger *logging.Logger) error {
+		return streamFrames(cfg, robo, options.file, decoder)
+	})
+	if decoder != nil {
+		_ = decoder.Close()
+	}
+	return err
+}
+
+// printMarker writes the printable marker to the output file or the standard output
+func printMarker(id int, output string, size float64) error {
+	if output == "" {
+		return vision.WriteMarkerSVG(os.Stdout, id, size)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := vision.WriteMarkerSVG(f, id, size); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
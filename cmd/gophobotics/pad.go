@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
+	"github.com/xitonix/gophobotics/fake"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/robot"
+)
+
+// statePause is long enough for the drone to broadcast its state a couple of times
+const statePause = 300 * time.Millisecond
+
+// padOptions are the flags of the pad command
+type padOptions struct {
+	pad, to, height, speed int
+	fake                   bool
+}
+
+func padFlags(flags *pflag.FlagSet) runner {
+	var options padOptions
+	flags.IntVar(&options.pad, "pad", 0, "The mission pad to fly over after taking off (1-8)")
+	flags.IntVar(&options.to, "to", 0, "The mission pad to jump to from the first one (1-8)")
+	flags.IntVar(&options.height, "height", 80, "The height in centimetres to hover at above the pads")
+	flags.IntVar(&options.speed, "speed", 50, "The speed of the drone in cm/s (10-100)")
+	flags.BoolVar(&options.fake, "fake", false, "Flies a local fake drone over fake pads in place of the real ones")
+	return func(cfg config.Config) error {
+		return runPad(cfg, options)
+	}
+}
+
+func runPad(cfg config.Config, options padOptions) error {
+	if options.pad == 0 {
+		return errors.New("the mission pad has not been specified")
+	}
+	if options.fake {
+		drone, err := startFakePads(&cfg, options.pad, options.to)
+		if err != nil {
+			return err
+		}
+		defer drone.Close()
+	}
+
+	logger, closer, err := cfg.OpenLogger()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	robo, err := robot.New(cfg.Robot, cfg.RobotOptions(logger, nil))
+	if err != nil {
+		return err
+	}
+	drone, ok := robo.(*robot.SDK)
+	if !ok {
+		return fmt.Errorf("the %s robot cannot fly over the mission pads", cfg.Robot)
+	}
+	if err := drone.Dial(); err != nil {
+		return err
+	}
+	defer func() {
+		if err := drone.Close(); err != nil {
+			logger.Errorf("%s", err)
+		}
+	}()
+
+	if err := drone.Execute(input.TakeOff); err != nil {
+		return err
+	}
+	fmt.Printf("Flying over pad %d\n", options.pad)
+	if err := drone.FlyToPad(options.pad, 0, 0, options.height, options.speed); err != nil {
+		return err
+	}
+	printPosition(drone)
+
+	if options.to != 0 {
+		layout := cfg.PadLayout()
+		from, fromOK := layout[options.pad]
+		to, toOK := layout[options.to]
+		if !fromOK || !toOK {
+			return fmt.Errorf("the positions of the pads %d and %d need to be in the config file to jump between them", options.pad, options.to)
+		}
+		fmt.Printf("Jumping to pad %d\n", options.to)
+		if err := drone.Jump(options.pad, options.to, int(to.X-from.X), int(to.Y-from.Y), options.height, options.speed, 0); err != nil {
+			return err
+		}
+		printPosition(drone)
+	}
+	if err := drone.Execute(input.Land); err != nil {
+		return err
+	}
+	return nil
+}
+
+func printPosition(drone *robot.SDK) {
+	// Give the drone a moment to report where it has ended up
+	time.Sleep(statePause)
+	pad := drone.State().Pad
+	if !pad.Detected() {
+		fmt.Println("No mission pad in sight")
+		return
+	}
+	fmt.Printf("Over pad %d at x:%d y:%d z:%d, heading %d°\n", pad.ID, pad.X, pad.Y, pad.Z, pad.Yaw)
+	if position, _, ok := drone.Position(); ok {
+		fmt.Printf("Position x:%.0f y:%.0f z:%.0f\n", position.X, position.Y, position.Z)
+	}
+}
+
+// startFakePads starts a local fake drone with the pads of the config file, or with the requested pads a metre apart
+func startFakePads(cfg *config.Config, pad, to int) (*fake.SDK, error) {
+	drone, err := fake.NewSDK("127.0.0.1:0", cfg.Drone.StatePort)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.MissionPads.Pads) == 0 {
+		cfg.MissionPads.Pads = []config.Pad{{ID: pad}}
+		if to != 0 {
+			cfg.MissionPads.Pads = append(cfg.MissionPads.Pads, config.Pad{ID: to, X: 100})
+		}
+	}
+	for _, p := range cfg.MissionPads.Pads {
+		drone.AddPad(p.ID, p.X, p.Y)
+	}
+	cfg.Drone.Address = "127.0.0.1"
+	cfg.Drone.CommandPort = drone.Port()
+	fmt.Printf("Fake drone is listening on port %d\n", drone.Port())
+	return drone, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
+	"github.com/xitonix/gophobotics/robot"
+	"github.com/xitonix/gophobotics/vision"
+)
+
+const (
+	// telemetryInterval is how often the drone state is sampled for the overlay
+	telemetryInterval = 100 * time.Millisecond
+	// overlayBatteryWarning is the battery percentage below which the overlay highlights the battery
+	overlayBatteryWarning = 20
+)
+
+// recordOptions are the flags of the record command
+type recordOptions struct {
+	output  string
+	overlay bool
+	latency time.Duration
+	paused  bool
+}
+
+// stateRobot is a robot which reports the state of the drone
+type stateRobot interface {
+	State() robot.DroneState
+}
+
+// recordingRobot is a robot which can pause and resume the recording using the ToggleRecording command
+type recordingRobot interface {
+	SetRecorder(recorder robot.Recorder)
+}
+
+func recordFlags(flags *pflag.FlagSet) runner {
+	var options recordOptions
+	flags.StringVar(&options.output, "output", "flight.mp4", "The video file to record the flight to")
+	flags.BoolVar(&options.overlay, "overlay", true, "Draws the height, speed, battery, Wi-Fi strength and the latest command onto the video")
+	flags.DurationVar(&options.latency, "latency", 200*time.Millisecond, "How far the picture lags behind the telemetry, which is taken into account when they are matched")
+	flags.BoolVar(&options.paused, "paused", false, "Waits for the ToggleRecording command (V) before recording")
+	return func(cfg config.Config) error {
+		return runRecord(cfg, options)
+	}
+}
+
+func runRecord(cfg config.Config, options recordOptions) error {
+	timeline := vision.NewTimeline(10 * time.Second)
+	stop := make(chan interface{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	wrap := func(pilot input.Runner, logger *logging.Logger, bus *event.Bus) (input.Runner, error) {
+		commands := bus.Subscribe(event.CommandTriggered)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer commands.Cancel()
+			for {
+				select {
+				case <-stop:
+					return
+				case e := <-commands.Events():
+					if cmd, ok := e.Data.(input.Command); ok {
+						timeline.RecordCommand(e.Time, cmd)
+					}
+				}
+			}
+		}()
+		return pilot, nil
+	}
+
+	var decoder *vision.Decoder
+	var encoder *vision.Encoder
+	var recorded sync.WaitGroup
+	err := flyFrom(cfg, wrap, func(robo robot.Robot, logger *logging.Logger) error {
+		streamer, ok := robo.(videoRobot)
+		if !ok {
+			return fmt.Errorf("the %s robot does not support video", cfg.Robot)
+		}
+		var err error
+		if decoder, err = vision.NewDecoder(cfg.DecoderOptions(), logger); err != nil {
+			return err
+		}
+		if encoder, err = vision.NewEncoder(options.output, cfg.DecoderOptions(), logger); err != nil {
+			return err
+		}
+		if options.paused {
+			encoder.Toggle()
+		}
+		if recorder, ok := robo.(recordingRobot); ok {
+			recorder.SetRecorder(encoder)
+		} else if options.paused {
+			return fmt.Errorf("the %s robot cannot resume the recording", cfg.Robot)
+		}
+		meter(robo, decoder)
+		if reporter, ok := robo.(stateRobot); ok {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sampleTelemetry(reporter, timeline, stop)
+			}()
+		}
+		recorded.Add(1)
+		go func() {
+			defer recorded.Done()
+			record(decoder.Frames(), encoder, timeline, options, logger)
+		}()
+		return streamer.Video(decoder)
+	})
+
+	if decoder != nil {
+		_ = decoder.Close()
+	}
+	recorded.Wait()
+	if encoder != nil {
+		if closeErr := encoder.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			fmt.Printf("The flight has been recorded to %s\n", options.output)
+		}
+	}
+	return err
+}
+
+// record encodes the frames, with the telemetry drawn onto them if the overlay is enabled
+func record(frames <-chan vision.Frame, encoder *vision.Encoder, timeline *vision.Timeline, options recordOptions, logger *logging.Logger) {
+	for frame := range frames {
+		if options.overlay {
+			telemetry, cmd, reported := timeline.At(frame.At.Add(-options.latency))
+			vision.DrawOverlay(frame.Image, telemetry, cmd, reported, overlayBatteryWarning)
+		}
+		if err := encoder.Encode(frame); err != nil {
+			logger.Errorf("Video: %s", err)
+			return
+		}
+	}
+}
+
+// sampleTelemetry records the state of the drone until it's stopped
+func sampleTelemetry(reporter stateRobot, timeline *vision.Timeline, stop <-chan interface{}) {
+	ticker := time.NewTicker(telemetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			state := reporter.State()
+			if !state.Reported {
+				continue
+			}
+			timeline.Record(now, vision.Telemetry{
+				Height:  int(state.Height),
+				Speed:   int(state.Speed),
+				Battery: int(state.Battery),
+				Wifi:    int(state.WifiStrength),
+			})
+		}
+	}
+}
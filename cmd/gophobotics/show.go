@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/choreography"
+	"github.com/xitonix/gophobotics/config"
+	"github.com/xitonix/gophobotics/robot"
+)
+
+// showLead is the time the drones are given to get ready before the show starts
+const showLead = 3 * time.Second
+
+// showOptions are the flags of the show command
+type showOptions struct {
+	file    string
+	preview string
+	output  string
+	fake    bool
+}
+
+func showFlags(flags *pflag.FlagSet) runner {
+	var options showOptions
+	flags.StringVar(&options.file, "show", "", "The path to the JSON timeline of the show")
+	flags.StringVar(&options.preview, "preview", "", "Renders the planned paths instead of flying the show (ascii or svg)")
+	flags.StringVarP(&options.output, "output", "o", "", "Writes the preview into the specified file instead of the standard output")
+	flags.BoolVar(&options.fake, "fake", false, "Flies a local fake drone in place of every swarm member to rehearse without the real drones")
+	return func(cfg config.Config) error {
+		return runShow(cfg, options)
+	}
+}
+
+func runShow(cfg config.Config, options showOptions) error {
+	if options.file == "" {
+		return errors.New("the show file has not been specified")
+	}
+	show, err := choreography.Load(options.file)
+	if err != nil {
+		return err
+	}
+
+	if options.preview != "" {
+		return preview(show, options.preview, options.output)
+	}
+
+	if err := show.Validate(); err != nil {
+		return err
+	}
+
+	if options.fake {
+		stop, err := startFakes(&cfg)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+
+	logger, closer, err := cfg.OpenLogger()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	robo, err := robot.New(cfg.Robot, cfg.RobotOptions(logger, nil))
+	if err != nil {
+		return err
+	}
+	swarm, ok := robo.(*robot.Swarm)
+	if !ok {
+		return fmt.Errorf("the %s robot is not a swarm", cfg.Robot)
+	}
+	// Make sure every drone of the show is a member of the swarm
+	if err := swarm.Target(show.Drones()...); err != nil {
+		return err
+	}
+
+	if err := swarm.Dial(); err != nil {
+		return err
+	}
+	defer swarm.Close()
+
+	stop := make(chan interface{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			close(stop)
+		}
+	}()
+
+	fmt.Printf("%s starts in %s, press Ctrl+C to land all the drones\n", show.Name, showLead)
+	return show.Play(swarm, showLead, logger, stop)
+}
+
+// preview renders the planned paths of the show in the format (ascii or svg) into the output file, or the standard output if it's empty
+func preview(show *choreography.Show, format, output string) error {
+	var render func(io.Writer) error
+	switch format {
+	case "ascii":
+		render = func(w io.Writer) error { return show.ASCII(w, 72, 24) }
+	case "svg":
+		render = show.SVG
+	default:
+		return fmt.Errorf("unknown preview format %q, expected ascii or svg", format)
+	}
+	if output == "" {
+		return render(os.Stdout)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return render(f)
+}
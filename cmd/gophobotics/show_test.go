@@ -0,0 +1,26 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/xitonix/gophobotics/choreography"
+)
+
+func TestPreviewKeepsTheOutputOfAnUnknownFormat(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "show.txt")
+	if err := ioutil.WriteFile(output, []byte("previous preview"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := preview(&choreography.Show{}, "png", output); err == nil {
+		t.Fatal("Expected an unknown format to be rejected")
+	}
+	content, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "previous preview" {
+		t.Errorf("Expected the output file to be left untouched, got %q", content)
+	}
+}
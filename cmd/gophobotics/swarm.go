@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
+	"github.com/xitonix/gophobotics/fake"
+	"github.com/xitonix/gophobotics/logging"
+	"github.com/xitonix/gophobotics/robot"
+)
+
+// swarmOptions are the flags of the swarm command
+type swarmOptions struct {
+	fake bool
+}
+
+func swarmFlags(flags *pflag.FlagSet) runner {
+	var options swarmOptions
+	flags.BoolVar(&options.fake, "fake", false, "Flies a local fake drone in place of every swarm member to rehearse without the real drones")
+	return func(cfg config.Config) error {
+		return runSwarm(cfg, options)
+	}
+}
+
+func runSwarm(cfg config.Config, options swarmOptions) error {
+	if options.fake {
+		stop, err := startFakes(&cfg)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+
+	var swarm *robot.Swarm
+	err := fly(cfg, func(robo robot.Robot, _ *logging.Logger) error {
+		var ok bool
+		if swarm, ok = robo.(*robot.Swarm); !ok {
+			return fmt.Errorf("the %s robot is not a swarm", cfg.Robot)
+		}
+		return nil
+	})
+	if swarm != nil {
+		printStates(swarm.States())
+	}
+	return err
+}
+
+func printStates(states map[string]robot.DroneState) {
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := states[name]
+		if !s.Reported {
+			fmt.Printf("%s: no telemetry received\n", name)
+			continue
+		}
+		fmt.Printf("%s: battery %d%%\n", name, s.Battery)
+	}
+}
+
+// startFakes starts a local fake drone for every member of the swarm and points the members at them
+func startFakes(cfg *config.Config) (func(), error) {
+	var drones []*fake.Tello
+	stop := func() {
+		for _, drone := range drones {
+			_ = drone.Close()
+		}
+	}
+	for i := range cfg.Swarm {
+		drone, err := fake.NewTello("127.0.0.1:0")
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		drones = append(drones, drone)
+		cfg.Swarm[i].Address = "127.0.0.1"
+		cfg.Swarm[i].CommandPort = drone.Port()
+		fmt.Printf("Fake drone %s is listening on port %d\n", cfg.Swarm[i].Name, drone.Port())
+	}
+	return stop, nil
+}
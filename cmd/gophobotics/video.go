@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
+	"github.com/xitonix/gophobotics/logging"
+	"github.com/xitonix/gophobotics/robot"
+	"github.com/xitonix/gophobotics/viewer"
+	"github.com/xitonix/gophobotics/vision"
+)
+
+// videoRobot is a robot which can stream its video feed
+type videoRobot interface {
+	Video(output io.WriteCloser) error
+}
+
+// exposureRobot is a robot which adapts the exposure of its camera to the brightness of the picture
+type exposureRobot interface {
+	ReportBrightness(brightness float64)
+}
+
+// meter reports the brightness of the decoded frames to the robot, if it can adapt its exposure
+func meter(robo robot.Robot, decoder *vision.Decoder) {
+	if camera, ok := robo.(exposureRobot); ok {
+		decoder.SetMeter(camera.ReportBrightness)
+	}
+}
+
+// videoFlags registers no flags, the viewer is set up by the configuration
+func videoFlags(*pflag.FlagSet) runner {
+	return runVideo
+}
+
+func runVideo(cfg config.Config) error {
+	stop := func() {}
+	defer func() {
+		stop()
+	}()
+
+	return fly(cfg, func(robo robot.Robot, logger *logging.Logger) error {
+		streamer, ok := robo.(videoRobot)
+		if !ok {
+			return fmt.Errorf("the %s robot does not support video", cfg.Robot)
+		}
+		var err error
+		if cfg.Video.Viewer == "player" {
+			stop, err = play(cfg, streamer, logger)
+		} else {
+			stop, err = serve(cfg, robo, streamer, logger)
+		}
+		return err
+	})
+}
+
+// play pipes the video feed into the video player. The returned function stops the player.
+func play(cfg config.Config, streamer videoRobot, logger *logging.Logger) (func(), error) {
+	player := exec.Command(cfg.Video.Player, cfg.Video.Args...)
+	playerIn, err := player.StdinPipe()
+	if err != nil {
+		return func() {}, err
+	}
+	if err := player.Start(); err != nil {
+		return func() {}, fmt.Errorf("failed to start %s: %s", cfg.Video.Player, err)
+	}
+	go func() {
+		if err := player.Wait(); err != nil {
+			logger.Warnf("%s: %s", cfg.Video.Player, err)
+		}
+	}()
+	stop := func() {
+		_ = player.Process.Kill()
+	}
+	return stop, streamer.Video(playerIn)
+}
+
+// serve decodes the video feed and serves it to the web browsers along with the telemetry of the drone.
+// The returned function stops the viewer.
+func serve(cfg config.Config, robo robot.Robot, streamer videoRobot, logger *logging.Logger) (func(), error) {
+	decoder, err := vision.NewDecoder(cfg.DecoderOptions(), logger)
+	if err != nil {
+		return func() {}, err
+	}
+	view := viewer.New(cfg.Video.ViewerAddress, decoder.Frames(), logger)
+	if reporter, ok := robo.(stateRobot); ok {
+		view.SetTelemetry(func() viewer.Telemetry {
+			return viewer.DroneTelemetry(reporter.State())
+		})
+	}
+	stop := func() {
+		_ = view.Close()
+		_ = decoder.Close()
+	}
+	if err := view.Listen(); err != nil {
+		return stop, err
+	}
+	go func() {
+		if err := view.Start(); err != nil {
+			logger.Errorf("Viewer: %s", err)
+		}
+	}()
+	fmt.Printf("Watch the video feed on %s\n", view.URL())
+	meter(robo, decoder)
+	return stop, streamer.Video(decoder)
+}
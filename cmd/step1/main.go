@@ -6,22 +6,45 @@ import (
 
 	"github.com/SMerrony/tello"
 	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
 	"github.com/xitonix/gophobotics/prnt"
 )
 
 func main() {
 	de := pflag.BoolP("disable-emoticons", "d", false, "Disables emoticon printing")
+	defaults := config.Default()
+	defaults.Drone.LocalPort = 8800
+	config.RegisterFlags(pflag.CommandLine, defaults, "drone-address", "drone-port", "local-port", "profile", "profiles")
 	pflag.Parse()
 	em := prnt.NewEmotifier(!*de)
 
+	cfg, err := config.Load(pflag.CommandLine, defaults)
+	if err != nil {
+		em.Printf("❌", "Invalid configuration: %s\n", err)
+		os.Exit(1)
+	}
+	prof := cfg.ActiveProfile
+	if prof != nil {
+		em.Printf("🎓", "Profile: %s\n", prof)
+	}
+
 	drone := tello.Tello{}
 
 	em.Println("✈️", " Preparing the flight...")
-	err := drone.ControlConnectDefault()
+	err = drone.ControlConnect(cfg.Drone.Address, cfg.Drone.CommandPort, cfg.Drone.LocalPort)
 	if err != nil {
 		em.Printf("❌", "Failed to establish connection: %s\n", err)
 		os.Exit(1)
 	}
+	if prof != nil {
+		// Give the drone a moment to report its flight data
+		time.Sleep(time.Second)
+		if battery := int(drone.GetFlightData().BatteryPercentage); battery < prof.MinTakeOffBattery {
+			em.Printf("🔋", "The battery (%d%%) is too low for the %s profile\n", battery, prof.Name)
+			drone.ControlDisconnect()
+			os.Exit(1)
+		}
+	}
 	em.Println("🛫", "Starting a 10 seconds journey...")
 	drone.TakeOff()
 	time.Sleep(10 * time.Second)
@@ -6,33 +6,59 @@ import (
 
 	"github.com/SMerrony/tello"
 	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
 	"github.com/xitonix/gophobotics/prnt"
 )
 
 func main() {
 	de := pflag.BoolP("disable-emoticons", "d", false, "Disables emoticon printing")
+	defaults := config.Default()
+	defaults.Drone.LocalPort = 8800
+	defaults.Move = 80
+	config.RegisterFlags(pflag.CommandLine, defaults, "drone-address", "drone-port", "local-port", "move", "profile", "profiles")
 	pflag.Parse()
 	em := prnt.NewEmotifier(!*de)
 
+	cfg, err := config.Load(pflag.CommandLine, defaults)
+	if err != nil {
+		em.Printf("❌", "Invalid configuration: %s\n", err)
+		os.Exit(1)
+	}
+	prof := cfg.ActiveProfile
+	if prof != nil {
+		em.Printf("🎓", "Profile: %s\n", prof)
+	}
+
 	drone := &tello.Tello{}
 
 	em.Println("✈️", " Preparing the flight...")
-	err := drone.ControlConnectDefault()
+	err = drone.ControlConnect(cfg.Drone.Address, cfg.Drone.CommandPort, cfg.Drone.LocalPort)
 	if err != nil {
 		em.Printf("❌", "Failed to establish connection: %s\n", err)
 		os.Exit(1)
 	}
+	if prof != nil {
+		// Give the drone a moment to report its flight data
+		time.Sleep(time.Second)
+		if battery := int(drone.GetFlightData().BatteryPercentage); battery < prof.MinTakeOffBattery {
+			em.Printf("🔋", "The battery (%d%%) is too low for the %s profile\n", battery, prof.Name)
+			drone.ControlDisconnect()
+			os.Exit(1)
+		}
+	}
+
+	speed := cfg.Move
 
 	em.Println("🛫", "Starting a quick journey...")
 	drone.TakeOff()
 	time.Sleep(5 * time.Second)
 
 	em.Println("⏩", "Flying left...")
-	move(drone, "left")
+	move(drone, "left", speed)
 	time.Sleep(2 * time.Second)
 
 	em.Println("⏪", "Flying Right...")
-	move(drone, "right")
+	move(drone, "right", speed)
 	time.Sleep(2 * time.Second)
 
 	em.Println("🛬", "Landing...")
@@ -42,12 +68,12 @@ func main() {
 	em.Println("🏡", "Welcome home!")
 }
 
-func move(drone *tello.Tello, move string) {
+func move(drone *tello.Tello, move string, speed int) {
 	switch move {
 	case "left":
-		drone.Left(80)
+		drone.Left(speed)
 	case "right":
-		drone.Right(80)
+		drone.Right(speed)
 	}
 
 	time.Sleep(500 * time.Millisecond)
@@ -1,32 +1,63 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"sync"
 
 	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
 	"github.com/xitonix/gophobotics/input"
 	"github.com/xitonix/gophobotics/robot"
 )
 
 func main() {
-	v := pflag.CountP("verbose", "v", "Enables verbose mode. You can enable extra verbosity by using -vv")
+	defaults := config.Default()
+	defaults.Robot = "echo"
+	config.RegisterFlags(pflag.CommandLine, defaults)
 	pflag.Parse()
 
-	source := input.NewKeyboard(input.ParseVerbosity(*v))
-	robo := robot.NewEcho()
+	cfg, err := config.Load(pflag.CommandLine, defaults)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logger, closer, err := cfg.OpenLogger()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closer.Close()
+
+	if cfg.ActiveProfile != nil {
+		fmt.Printf("Profile: %s\n", cfg.ActiveProfile)
+	}
+
+	source, err := input.New(cfg.Source, cfg.SourceOptions(logger, nil))
+	if err != nil {
+		log.Fatal(err)
+	}
+	pipeline := input.NewPipeline(source, logger, cfg.Middlewares()...)
+
+	robo, err := robot.New(cfg.Robot, cfg.RobotOptions(logger, nil))
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		err := robo.Connect(source)
+		err := robo.Connect(pipeline)
 		if err != nil {
 			log.Fatal(err)
 		}
 	}()
 
-	err := source.Start()
+	go func() {
+		_ = pipeline.Start()
+	}()
+
+	err = source.Start()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -1,44 +1,76 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os/exec"
 
 	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
 	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
 	"github.com/xitonix/gophobotics/robot"
+	"github.com/xitonix/gophobotics/viewer"
+	"github.com/xitonix/gophobotics/vision"
 )
 
 func main() {
-	v := pflag.CountP("verbose", "v", "Enables verbose mode. You can enable extra verbosity by using -vv")
-	maxMoves := pflag.IntP("max-moves", "m", 6, "Maximum number of allowed forward/backward/left/right moves")
+	defaults := config.Default()
+	defaults.MaxMoves = 6
+	config.RegisterFlags(pflag.CommandLine, defaults)
 	pflag.Parse()
 
-	verbosity := input.ParseVerbosity(*v)
-	source := input.NewKeyboard(verbosity)
-	robo := robot.NewTello(30, *maxMoves, verbosity)
+	cfg, err := config.Load(pflag.CommandLine, defaults)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	mplayer := exec.Command("mplayer", "-fps", "60", "-")
+	logger, closer, err := cfg.OpenLogger()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closer.Close()
 
-	mplayerIn, err := mplayer.StdinPipe()
-	if nil != err {
-		panic(err)
+	if cfg.ActiveProfile != nil {
+		fmt.Printf("Profile: %s\n", cfg.ActiveProfile)
 	}
 
-	if err := mplayer.Start(); err != nil {
+	source := input.NewKeyboard(logger)
+
+	pipeline := input.NewPipeline(source, logger, cfg.Middlewares()...)
+
+	robo, err := robot.NewTelloAt(cfg.TelloAddress(), cfg.Move, cfg.MaxMoves, logger)
+	if err != nil {
 		log.Fatal(err)
 	}
-	if err := robo.Video(mplayerIn); nil != err {
+	if cfg.ActiveProfile != nil {
+		robo.SetLimits(cfg.ActiveProfile.Limits())
+	}
+	robo.SuperviseLink(cfg.LinkPolicy())
+
+	stopVideo, err := watch(cfg, robo, logger)
+	if err != nil {
 		log.Fatal(err)
 	}
+	defer stopVideo()
+
+	go func() {
+		for err := range robo.Errors() {
+			fmt.Printf("Err: %s\n", err)
+		}
+	}()
 
 	go func() {
-		err := robo.Connect(source)
+		err := robo.Connect(pipeline)
 		if err != nil {
 			log.Fatal(err)
 		}
 	}()
 
+	go func() {
+		_ = pipeline.Start()
+	}()
+
 	go func() {
 		err = source.Start()
 		if err != nil {
@@ -46,12 +78,48 @@ func main() {
 		}
 	}()
 
+	robo.MonitorTermination()
+}
+
+// watch starts the video viewer. The video is served to the web browser by default, or piped into mplayer if the player viewer has been selected.
+// The returned function stops the viewer.
+func watch(cfg config.Config, robo *robot.Tello, logger *logging.Logger) (func(), error) {
+	if cfg.Video.Viewer == "player" {
+		mplayer := exec.Command(cfg.Video.Player, cfg.Video.Args...)
+		mplayerIn, err := mplayer.StdinPipe()
+		if nil != err {
+			return nil, err
+		}
+		if err := mplayer.Start(); err != nil {
+			return nil, err
+		}
+		go func() {
+			if err := mplayer.Wait(); err != nil {
+				log.Printf("mplayer:%s\n", err)
+			}
+		}()
+		return func() { _ = mplayer.Process.Kill() }, robo.Video(mplayerIn)
+	}
+
+	decoder, err := vision.NewDecoder(cfg.DecoderOptions(), logger)
+	if err != nil {
+		return nil, err
+	}
+	view := viewer.New(cfg.Video.ViewerAddress, decoder.Frames(), logger)
+	view.SetTelemetry(func() viewer.Telemetry {
+		return viewer.DroneTelemetry(robo.State())
+	})
+	if err := view.Listen(); err != nil {
+		return nil, err
+	}
 	go func() {
-		robo.MonitorTermination()
-		_ = mplayer.Process.Kill()
+		_ = view.Start()
 	}()
-
-	if err := mplayer.Wait(); err != nil {
-		log.Printf("mplayer:%s\n", err)
+	fmt.Printf("Open %s in your browser to watch the video\n", view.URL())
+	stop := func() {
+		_ = view.Close()
+		_ = decoder.Close()
 	}
+	decoder.SetMeter(robo.ReportBrightness)
+	return stop, robo.Video(decoder)
 }
@@ -1,23 +1,49 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"sync"
 
 	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/config"
 	"github.com/xitonix/gophobotics/input"
 	"github.com/xitonix/gophobotics/robot"
 )
 
 func main() {
-	v := pflag.CountP("verbose", "v", "Enables verbose mode. You can enable extra verbosity by using -vv")
-	maxMoves := pflag.IntP("max-moves", "m", 4, "Maximum number of allowed movements")
+	defaults := config.Default()
+	defaults.Source = "makey-makey"
+	config.RegisterFlags(pflag.CommandLine, defaults)
 	pflag.Parse()
-	verbosity := input.ParseVerbosity(*v)
-	source := input.NewKMakeyMakey(verbosity)
 
-	tello := robot.NewTello(30, *maxMoves, verbosity)
+	cfg, err := config.Load(pflag.CommandLine, defaults)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logger, closer, err := cfg.OpenLogger()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closer.Close()
+
+	if cfg.ActiveProfile != nil {
+		fmt.Printf("Profile: %s\n", cfg.ActiveProfile)
+	}
+
+	source, err := input.New(cfg.Source, cfg.SourceOptions(logger, nil))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pipeline := input.NewPipeline(source, logger, cfg.Middlewares()...)
+
+	tello, err := robot.New(cfg.Robot, cfg.RobotOptions(logger, nil))
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	var wg sync.WaitGroup
 
@@ -25,6 +51,10 @@ func main() {
 	go func() {
 		defer wg.Done()
 		for err := range tello.Errors() {
+			if errors.Is(err, robot.ErrOverLimit) {
+				fmt.Printf("Err: %s. Try the opposite direction first\n", err)
+				continue
+			}
 			fmt.Printf("Err: %s\n", err)
 		}
 	}()
@@ -32,13 +62,17 @@ func main() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		err := tello.Connect(source)
+		err := tello.Connect(pipeline)
 		if err != nil {
 			log.Fatal(err)
 		}
 	}()
 
-	err := source.Start()
+	go func() {
+		_ = pipeline.Start()
+	}()
+
+	err = source.Start()
 	if err != nil {
 		log.Fatal(err)
 	}
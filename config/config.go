@@ -0,0 +1,457 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
+	"github.com/xitonix/gophobotics/profile"
+	"github.com/xitonix/gophobotics/robot"
+	"github.com/xitonix/gophobotics/viewer"
+	"github.com/xitonix/gophobotics/vision"
+)
+
+// Config is the shared configuration of the gophobotics programs
+type Config struct {
+	// Robot is the name of the robot to control (echo, tello, tello-sdk or swarm)
+	Robot string `json:"robot"`
+	// Source is the name of the input source (keyboard or makey-makey)
+	Source string `json:"source"`
+	// Drone is the network settings of the drone
+	Drone Drone `json:"drone"`
+	// Swarm is the drones of the swarm robot
+	Swarm []Member `json:"swarm"`
+	// Target is the names of the swarm members or groups the commands are sent to. The commands are broadcast if it's empty.
+	Target []string `json:"target"`
+	// MissionPads is the mission pad settings of the tello-sdk robot
+	MissionPads MissionPads `json:"mission_pads"`
+	// Move is the speed of the drone (0-100)
+	Move int `json:"move"`
+	// MaxMoves is the maximum number of moves in each direction. Zero means no limit.
+	MaxMoves int `json:"max_moves"`
+	// Failsafe is the action to take if the connection is lost while flying (hover or land)
+	Failsafe string `json:"failsafe"`
+	// Profile is the name of the flight profile
+	Profile string `json:"profile"`
+	// Profiles is the path to a JSON file with custom flight profiles
+	Profiles string `json:"profiles"`
+	// Markers is the commands triggered by the visual markers
+	Markers []MarkerAction `json:"markers"`
+	// Input is the settings of the input middlewares
+	Input Input `json:"input"`
+	// Video is the settings of the video viewer and the frame decoder
+	Video Video `json:"video"`
+	// Log is the logging settings
+	Log Log `json:"log"`
+
+	// ActiveProfile is the flight profile resolved from Profile. It is nil if no profile has been selected.
+	ActiveProfile *profile.Profile `json:"-"`
+}
+
+// MissionPads is the mission pad settings of the Tello EDU drones
+type MissionPads struct {
+	// Enabled turns on the mission pad detection
+	Enabled bool `json:"enabled"`
+	// Pads is where each pad has been placed. X points to the front of the pads, Y to the left and Z up.
+	Pads []Pad `json:"pads"`
+	// Fence is the box the drone is allowed to fly in, based on the pad positions. There is no fence if it's nil.
+	Fence *robot.Fence `json:"fence"`
+}
+
+// Pad is the position of a mission pad in centimetres
+type Pad struct {
+	ID int     `json:"id"`
+	X  float64 `json:"x"`
+	Y  float64 `json:"y"`
+	Z  float64 `json:"z"`
+}
+
+// MarkerAction is the sequence of commands a visual marker triggers
+type MarkerAction struct {
+	// ID is the number encoded in the marker
+	ID int `json:"id"`
+	// Commands are the names of the commands to issue in order (ie. RotateRight, Forward)
+	Commands []string `json:"commands"`
+}
+
+// Drone is the network settings of the drone
+type Drone struct {
+	// Address is the IP address of the drone
+	Address string `json:"address"`
+	// CommandPort is the UDP port the drone listens on for the commands
+	CommandPort int `json:"command_port"`
+	// LocalPort is the local UDP port the responses are received on
+	LocalPort int `json:"local_port"`
+	// VideoPort is the local UDP port the video is streamed to
+	VideoPort int `json:"video_port"`
+	// StatePort is the local UDP port the drone state is received on by the tello-sdk robot
+	StatePort int `json:"state_port"`
+}
+
+// Member is a drone of the swarm
+type Member struct {
+	// Name identifies the drone in the swarm
+	Name string `json:"name"`
+	// Address is the IP address of the drone
+	Address string `json:"address"`
+	// CommandPort is the UDP port the drone listens on for the commands
+	CommandPort int `json:"command_port"`
+	// LocalPort is the local UDP port the responses are received on. Each member needs its own port.
+	LocalPort int `json:"local_port"`
+	// Groups are the names of the groups the drone belongs to
+	Groups []string `json:"groups"`
+}
+
+// Input is the settings of the input middlewares
+type Input struct {
+	// Mirror swaps the left and right commands
+	Mirror bool `json:"mirror"`
+	// Block is the list of the commands which are not allowed
+	Block []string `json:"block"`
+	// Debounce ignores the repeated commands within the specified period
+	Debounce Duration `json:"debounce"`
+	// RateLimit is the minimum period between two commands
+	RateLimit Duration `json:"rate_limit"`
+}
+
+// Video is the settings of the video viewer and the frame decoder
+type Video struct {
+	// Viewer is how the video feed is watched: browser serves it to a web browser, player pipes it into the video player
+	Viewer string `json:"viewer"`
+	// ViewerAddress is the address the browser viewer listens on
+	ViewerAddress string `json:"viewer_address"`
+	// Player is the video player command
+	Player string `json:"player"`
+	// Args are the arguments of the video player. The video is written to the player's standard input.
+	Args []string `json:"args"`
+	// FFmpeg is the path to the ffmpeg executable which decodes the video into frames
+	FFmpeg string `json:"ffmpeg"`
+	// BitRate is the bit rate of the drone video: adaptive steps it according to the Wi-Fi conditions, auto lets the drone choose,
+	// or a fixed rate (1M, 1.5M, 2M, 3M or 4M)
+	BitRate string `json:"bit_rate"`
+	// Exposure is the exposure level of the drone camera: auto adapts it to the light, or a fixed level (0, 1 or 2)
+	Exposure string `json:"exposure"`
+	// FrameWidth and FrameHeight are the size of the decoded frames in pixels
+	FrameWidth  int `json:"frame_width"`
+	FrameHeight int `json:"frame_height"`
+	// FrameRate is the number of frames decoded per second
+	FrameRate int `json:"frame_rate"`
+}
+
+// Log is the logging settings
+type Log struct {
+	// Verbosity is the number of -v flags
+	Verbosity int `json:"verbosity"`
+	// Format is the format of the logs (text or json)
+	Format string `json:"format"`
+	// File is the path of the log file. The logs are written to standard output if it's empty.
+	File string `json:"file"`
+}
+
+// Duration is a time.Duration which is written as a string (ie. "200ms") in the config files
+type Duration time.Duration
+
+// UnmarshalJSON parses the duration from a string or a number of nanoseconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int64
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid duration %s", data)
+		}
+		*d = Duration(n)
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Default returns the default configuration
+func Default() Config {
+	return Config{
+		Robot:  "tello",
+		Source: "keyboard",
+		Drone: Drone{
+			Address:     "192.168.10.1",
+			CommandPort: 8889,
+			LocalPort:   8888,
+			VideoPort:   6038,
+			StatePort:   8890,
+		},
+		Move:     30,
+		MaxMoves: 4,
+		Failsafe: "hover",
+		Video: Video{
+			Viewer:        "browser",
+			ViewerAddress: viewer.DefaultAddress,
+
+			Player: "mplayer",
+			Args:   []string{"-fps", "60", "-"},
+			FFmpeg: "ffmpeg",
+
+			BitRate:  "adaptive",
+			Exposure: "auto",
+
+			FrameWidth:  480,
+			FrameHeight: 360,
+			FrameRate:   10,
+		},
+		Log: Log{
+			Format: "text",
+		},
+	}
+}
+
+// Validate checks the configuration before any connection is made
+func (c Config) Validate() error {
+	if !robot.IsRegistered(c.Robot) {
+		return fmt.Errorf("unknown robot %q", c.Robot)
+	}
+	if !input.IsRegistered(c.Source) {
+		return fmt.Errorf("unknown source %q", c.Source)
+	}
+	if c.Move <= 0 || c.Move > 100 {
+		return fmt.Errorf("move must be between 1 and 100")
+	}
+	if c.MaxMoves < 0 {
+		return fmt.Errorf("max moves cannot be negative")
+	}
+	switch c.Failsafe {
+	case "hover", "land":
+	default:
+		return fmt.Errorf("unknown failsafe %q, expected hover or land", c.Failsafe)
+	}
+	if c.Robot == "swarm" && len(c.Swarm) == 0 {
+		return fmt.Errorf("the swarm robot needs at least one drone")
+	}
+	if c.Robot == "swarm" && c.Failsafe != "hover" {
+		return fmt.Errorf("the swarm robot does not supervise the link, so its drones can only hover when they lose it")
+	}
+	localPorts := make(map[int]string)
+	for _, m := range c.Swarm {
+		if m.Name == "" || m.Address == "" {
+			return fmt.Errorf("the swarm members need a name and an address")
+		}
+		if m.LocalPort <= 0 || m.LocalPort > 65535 {
+			return fmt.Errorf("invalid local port %d for the swarm member %s", m.LocalPort, m.Name)
+		}
+		if other, ok := localPorts[m.LocalPort]; ok {
+			return fmt.Errorf("the swarm members %s and %s cannot share the local port %d", other, m.Name, m.LocalPort)
+		}
+		localPorts[m.LocalPort] = m.Name
+	}
+	pads := make(map[int]bool)
+	for _, p := range c.MissionPads.Pads {
+		if p.ID < 1 || p.ID > 8 {
+			return fmt.Errorf("invalid mission pad %d, expected 1-8", p.ID)
+		}
+		if pads[p.ID] {
+			return fmt.Errorf("duplicate mission pad %d", p.ID)
+		}
+		pads[p.ID] = true
+	}
+	if c.MissionPads.Fence != nil && (!c.MissionPads.Enabled || len(pads) == 0) {
+		return fmt.Errorf("the fence needs the mission pads to be enabled and laid out")
+	}
+	markers := make(map[int]bool)
+	for _, m := range c.Markers {
+		if m.ID < 0 || m.ID > vision.MaxMarkerID {
+			return fmt.Errorf("invalid marker %d, expected 0-%d", m.ID, vision.MaxMarkerID)
+		}
+		if markers[m.ID] {
+			return fmt.Errorf("duplicate marker %d", m.ID)
+		}
+		markers[m.ID] = true
+		if len(m.Commands) == 0 {
+			return fmt.Errorf("no commands for marker %d", m.ID)
+		}
+		if _, err := input.ParseCommands(m.Commands); err != nil {
+			return fmt.Errorf("invalid action for marker %d: %s", m.ID, err)
+		}
+	}
+	if c.Drone.Address == "" {
+		return fmt.Errorf("the drone address cannot be empty")
+	}
+	for name, port := range map[string]int{"command": c.Drone.CommandPort, "local": c.Drone.LocalPort, "video": c.Drone.VideoPort, "state": c.Drone.StatePort} {
+		if port <= 0 || port > 65535 {
+			return fmt.Errorf("invalid %s port %d", name, port)
+		}
+	}
+	switch c.Video.Viewer {
+	case "browser", "player":
+	default:
+		return fmt.Errorf("unknown viewer %q, expected browser or player", c.Video.Viewer)
+	}
+	if c.Video.BitRate != "adaptive" {
+		if _, err := robot.ParseVideoBitRate(c.Video.BitRate); err != nil {
+			return err
+		}
+	}
+	if c.Video.Exposure != "auto" {
+		if _, err := robot.ParseExposure(c.Video.Exposure); err != nil {
+			return err
+		}
+	}
+	if c.Video.FrameWidth <= 0 || c.Video.FrameHeight <= 0 || c.Video.FrameRate <= 0 {
+		return fmt.Errorf("invalid frame size %dx%d at %d fps", c.Video.FrameWidth, c.Video.FrameHeight, c.Video.FrameRate)
+	}
+	if _, err := input.ParseCommands(c.Input.Block); err != nil {
+		return err
+	}
+	if c.Input.Debounce < 0 || c.Input.RateLimit < 0 {
+		return fmt.Errorf("the input periods cannot be negative")
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown log format %q, expected text or json", c.Log.Format)
+	}
+	return nil
+}
+
+// TelloAddress returns where the Tello drone can be reached
+func (c Config) TelloAddress() robot.TelloAddress {
+	return robot.TelloAddress{
+		IP:          c.Drone.Address,
+		CommandPort: c.Drone.CommandPort,
+		LocalPort:   c.Drone.LocalPort,
+		VideoPort:   c.Drone.VideoPort,
+		StatePort:   c.Drone.StatePort,
+	}
+}
+
+// RobotOptions returns the settings the robot is created with
+func (c Config) RobotOptions(logger *logging.Logger, bus *event.Bus) robot.Options {
+	policy := c.LinkPolicy()
+	options := robot.Options{
+		Move:     c.Move,
+		MaxMoves: c.MaxMoves,
+		Address:  c.TelloAddress(),
+		Policy:   &policy,
+		Swarm:    c.SwarmMembers(),
+		Targets:  c.Target,
+		Logger:   logger,
+		Bus:      bus,
+
+		MissionPads: c.MissionPads.Enabled,
+		Pads:        c.PadLayout(),
+		Fence:       c.MissionPads.Fence,
+	}
+	if rate, err := robot.ParseVideoBitRate(c.Video.BitRate); err == nil {
+		options.VideoBitRate = &rate
+	}
+	if level, err := robot.ParseExposure(c.Video.Exposure); err == nil {
+		options.Exposure = &level
+	}
+	if c.ActiveProfile != nil {
+		limits := c.ActiveProfile.Limits()
+		options.Limits = &limits
+	}
+	return options
+}
+
+// DecoderOptions returns the settings of the frame decoder
+func (c Config) DecoderOptions() vision.Options {
+	options := vision.DefaultOptions()
+	options.FFmpeg = c.Video.FFmpeg
+	options.Width = c.Video.FrameWidth
+	options.Height = c.Video.FrameHeight
+	options.Rate = float64(c.Video.FrameRate)
+	return options
+}
+
+// MarkerActions returns the commands each visual marker triggers by marker ID
+func (c Config) MarkerActions() (map[int][]input.Command, error) {
+	actions := make(map[int][]input.Command, len(c.Markers))
+	for _, m := range c.Markers {
+		commands, err := input.ParseCommands(m.Commands)
+		if err != nil {
+			return nil, fmt.Errorf("invalid action for marker %d: %s", m.ID, err)
+		}
+		actions[m.ID] = commands
+	}
+	return actions, nil
+}
+
+// PadLayout returns where the mission pads have been placed by pad ID
+func (c Config) PadLayout() robot.PadLayout {
+	layout := make(robot.PadLayout, len(c.MissionPads.Pads))
+	for _, p := range c.MissionPads.Pads {
+		layout[p.ID] = robot.Position{X: p.X, Y: p.Y, Z: p.Z}
+	}
+	return layout
+}
+
+// SwarmMembers returns the drones of the swarm robot
+func (c Config) SwarmMembers() []robot.SwarmMember {
+	members := make([]robot.SwarmMember, 0, len(c.Swarm))
+	for _, m := range c.Swarm {
+		members = append(members, robot.SwarmMember{
+			Name: m.Name,
+			Address: robot.TelloAddress{
+				IP:          m.Address,
+				CommandPort: m.CommandPort,
+				LocalPort:   m.LocalPort,
+			},
+			Groups: m.Groups,
+		})
+	}
+	return members
+}
+
+// SourceOptions returns the settings the input source is created with
+func (c Config) SourceOptions(logger *logging.Logger, bus *event.Bus) input.Options {
+	return input.Options{
+		Logger: logger,
+		Bus:    bus,
+	}
+}
+
+// OpenLogger creates the configured logger. The returned closer must be called before the program exits.
+func (c Config) OpenLogger() (*logging.Logger, io.Closer, error) {
+	return logging.Open(logging.ParseVerbosity(c.Log.Verbosity), logging.ParseFormat(c.Log.Format), c.Log.File)
+}
+
+// LinkPolicy returns the link supervision policy of the robot
+func (c Config) LinkPolicy() robot.LinkPolicy {
+	policy := robot.DefaultLinkPolicy()
+	policy.Failsafe = robot.ParseFailsafe(c.Failsafe)
+	return policy
+}
+
+// Middlewares returns the input middlewares, including the ones required by the active profile
+func (c Config) Middlewares() []input.Middleware {
+	var middlewares []input.Middleware
+	if c.Input.Debounce > 0 {
+		middlewares = append(middlewares, input.Debounce(time.Duration(c.Input.Debounce)))
+	}
+	if c.Input.RateLimit > 0 {
+		middlewares = append(middlewares, input.RateLimit(time.Duration(c.Input.RateLimit)))
+	}
+	if len(c.Input.Block) > 0 {
+		// The configuration has been validated, so all the names are known
+		blocked, _ := input.ParseCommands(c.Input.Block)
+		middlewares = append(middlewares, input.Block(blocked...))
+	}
+	if c.Input.Mirror {
+		middlewares = append(middlewares, input.Mirror())
+	}
+	if c.ActiveProfile != nil {
+		middlewares = append(middlewares, c.ActiveProfile.Middlewares()...)
+	}
+	return middlewares
+}
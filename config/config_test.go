@@ -0,0 +1,82 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestValidateSwarm(t *testing.T) {
+	testCases := []struct {
+		title    string
+		members  []Member
+		failsafe string
+		valid    bool
+	}{
+		{
+			title: "distinct local ports",
+			members: []Member{
+				{Name: "alpha", Address: "192.168.1.11", CommandPort: 8889, LocalPort: 8890},
+				{Name: "bravo", Address: "192.168.1.12", CommandPort: 8889, LocalPort: 8891},
+			},
+			valid: true,
+		},
+		{
+			title: "missing local port",
+			members: []Member{
+				{Name: "alpha", Address: "192.168.1.11", CommandPort: 8889, LocalPort: 8890},
+				{Name: "bravo", Address: "192.168.1.12", CommandPort: 8889},
+			},
+		},
+		{
+			title: "shared local port",
+			members: []Member{
+				{Name: "alpha", Address: "192.168.1.11", CommandPort: 8889, LocalPort: 8890},
+				{Name: "bravo", Address: "192.168.1.12", CommandPort: 8889, LocalPort: 8890},
+			},
+		},
+		{
+			title: "no members",
+		},
+		{
+			title:    "landing failsafe",
+			members:  []Member{{Name: "alpha", Address: "192.168.1.11", CommandPort: 8889, LocalPort: 8890}},
+			failsafe: "land",
+		},
+		{
+			title:   "missing address",
+			members: []Member{{Name: "alpha", CommandPort: 8889, LocalPort: 8890}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			cfg := Default()
+			cfg.Robot = "swarm"
+			cfg.Swarm = tc.members
+			if tc.failsafe != "" {
+				cfg.Failsafe = tc.failsafe
+			}
+			err := cfg.Validate()
+			if tc.valid && err != nil {
+				t.Errorf("Expected the swarm to be valid, got %s", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("Expected the swarm to be invalid")
+			}
+		})
+	}
+}
+
+func TestSetMembersAssignsLocalPorts(t *testing.T) {
+	var members []Member
+	if err := setMembers(&members, "alpha=192.168.1.11,bravo=192.168.1.12:9000:9100"); err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 2 {
+		t.Fatalf("Expected 2 members, got %d", len(members))
+	}
+	if members[0].LocalPort != swarmLocalPort || members[0].CommandPort != 8889 {
+		t.Errorf("Expected the default ports for alpha, got %+v", members[0])
+	}
+	if members[1].LocalPort != 9100 || members[1].CommandPort != 9000 {
+		t.Errorf("Expected the explicit ports for bravo, got %+v", members[1])
+	}
+}
@@ -0,0 +1,383 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/xitonix/gophobotics/profile"
+)
+
+// EnvPrefix is the prefix of the environment variables. The rest of the name is the flag name in upper case with underscores (ie. GOPHOBOTICS_MAX_MOVES).
+const EnvPrefix = "GOPHOBOTICS_"
+
+// configFlag is the name of the flag which specifies the path of the config file
+const configFlag = "config"
+
+type kind int8
+
+const (
+	stringKind kind = iota
+	intKind
+	boolKind
+	durationKind
+	countKind
+)
+
+// setting maps a configuration field to its flag and environment variable
+type setting struct {
+	flag, short, usage string
+	kind               kind
+	get                func(c *Config) string
+	set                func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{flag: "robot", usage: "The robot to control (echo, tello, tello-sdk or swarm)", kind: stringKind,
+		get: func(c *Config) string { return c.Robot },
+		set: func(c *Config, v string) error { c.Robot = v; return nil }},
+	{flag: "source", usage: "The input source (keyboard or makey-makey)", kind: stringKind,
+		get: func(c *Config) string { return c.Source },
+		set: func(c *Config, v string) error { c.Source = v; return nil }},
+	{flag: "drone-address", usage: "The IP address of the drone", kind: stringKind,
+		get: func(c *Config) string { return c.Drone.Address },
+		set: func(c *Config, v string) error { c.Drone.Address = v; return nil }},
+	{flag: "drone-port", usage: "The UDP port the drone listens on for the commands", kind: intKind,
+		get: func(c *Config) string { return strconv.Itoa(c.Drone.CommandPort) },
+		set: func(c *Config, v string) error { return setInt(&c.Drone.CommandPort, v) }},
+	{flag: "local-port", usage: "The local UDP port the drone responses are received on", kind: intKind,
+		get: func(c *Config) string { return strconv.Itoa(c.Drone.LocalPort) },
+		set: func(c *Config, v string) error { return setInt(&c.Drone.LocalPort, v) }},
+	{flag: "video-port", usage: "The local UDP port the video is streamed to", kind: intKind,
+		get: func(c *Config) string { return strconv.Itoa(c.Drone.VideoPort) },
+		set: func(c *Config, v string) error { return setInt(&c.Drone.VideoPort, v) }},
+	{flag: "state-port", usage: "The local UDP port the drone state is received on (tello-sdk only)", kind: intKind,
+		get: func(c *Config) string { return strconv.Itoa(c.Drone.StatePort) },
+		set: func(c *Config, v string) error { return setInt(&c.Drone.StatePort, v) }},
+	{flag: "mission-pads", usage: "Turns on the mission pad detection of the Tello EDU drones (tello-sdk only)", kind: boolKind,
+		get: func(c *Config) string { return strconv.FormatBool(c.MissionPads.Enabled) },
+		set: func(c *Config, v string) error { return setBool(&c.MissionPads.Enabled, v) }},
+	{flag: "swarm", usage: "Comma separated list of the swarm drones as name=ip[:command_port[:local_port]] (ie. alpha=192.168.1.11,bravo=192.168.1.12)", kind: stringKind,
+		get: func(c *Config) string { return formatMembers(c.Swarm) },
+		set: func(c *Config, v string) error { return setMembers(&c.Swarm, v) }},
+	{flag: "target", usage: "Comma separated list of the swarm drones or groups to send the commands to. The commands are broadcast by default", kind: stringKind,
+		get: func(c *Config) string { return strings.Join(c.Target, ",") },
+		set: func(c *Config, v string) error { c.Target = splitList(v); return nil }},
+	{flag: "move", usage: "The speed of the drone (1-100)", kind: intKind,
+		get: func(c *Config) string { return strconv.Itoa(c.Move) },
+		set: func(c *Config, v string) error { return setInt(&c.Move, v) }},
+	{flag: "max-moves", short: "m", usage: "Maximum number of allowed movements in each direction", kind: intKind,
+		get: func(c *Config) string { return strconv.Itoa(c.MaxMoves) },
+		set: func(c *Config, v string) error { return setInt(&c.MaxMoves, v) }},
+	{flag: "failsafe", short: "f", usage: "The action to take if the connection is lost while flying (hover or land)", kind: stringKind,
+		get: func(c *Config) string { return c.Failsafe },
+		set: func(c *Config, v string) error { c.Failsafe = v; return nil }},
+	{flag: "profile", short: "p", usage: "The flight profile (beginner, intermediate, expert or any profile defined in the profiles file)", kind: stringKind,
+		get: func(c *Config) string { return c.Profile },
+		set: func(c *Config, v string) error { c.Profile = v; return nil }},
+	{flag: "profiles", usage: "The path to a JSON file with custom flight profiles", kind: stringKind,
+		get: func(c *Config) string { return c.Profiles },
+		set: func(c *Config, v string) error { c.Profiles = v; return nil }},
+	{flag: "markers", usage: "Comma separated list of the commands the visual markers trigger as id=command[+command...] (ie. 3=RotateRight+Forward,5=Land)", kind: stringKind,
+		get: func(c *Config) string { return formatMarkers(c.Markers) },
+		set: func(c *Config, v string) error { return setMarkers(&c.Markers, v) }},
+	{flag: "mirror", usage: "Swaps the left and right commands", kind: boolKind,
+		get: func(c *Config) string { return strconv.FormatBool(c.Input.Mirror) },
+		set: func(c *Config, v string) error { return setBool(&c.Input.Mirror, v) }},
+	{flag: "block", usage: "Comma separated list of the commands which are not allowed (ie. FrontFlip,BackFlip)", kind: stringKind,
+		get: func(c *Config) string { return strings.Join(c.Input.Block, ",") },
+		set: func(c *Config, v string) error { c.Input.Block = splitList(v); return nil }},
+	{flag: "debounce", usage: "Ignores the repeated commands within the specified period", kind: durationKind,
+		get: func(c *Config) string { return time.Duration(c.Input.Debounce).String() },
+		set: func(c *Config, v string) error { return setDuration(&c.Input.Debounce, v) }},
+	{flag: "rate-limit", usage: "The minimum period between two commands", kind: durationKind,
+		get: func(c *Config) string { return time.Duration(c.Input.RateLimit).String() },
+		set: func(c *Config, v string) error { return setDuration(&c.Input.RateLimit, v) }},
+	{flag: "viewer", usage: "How the video feed is watched (browser or player)", kind: stringKind,
+		get: func(c *Config) string { return c.Video.Viewer },
+		set: func(c *Config, v string) error { c.Video.Viewer = v; return nil }},
+	{flag: "viewer-address", usage: "The address the browser viewer listens on", kind: stringKind,
+		get: func(c *Config) string { return c.Video.ViewerAddress },
+		set: func(c *Config, v string) error { c.Video.ViewerAddress = v; return nil }},
+	{flag: "video-player", usage: "The video player command, used by the player viewer", kind: stringKind,
+		get: func(c *Config) string { return c.Video.Player },
+		set: func(c *Config, v string) error { c.Video.Player = v; return nil }},
+	{flag: "video-args", usage: "The space separated arguments of the video player", kind: stringKind,
+		get: func(c *Config) string { return strings.Join(c.Video.Args, " ") },
+		set: func(c *Config, v string) error { c.Video.Args = strings.Fields(v); return nil }},
+	{flag: "bit-rate", usage: "The bit rate of the drone video (adaptive, auto, 1M, 1.5M, 2M, 3M or 4M)", kind: stringKind,
+		get: func(c *Config) string { return c.Video.BitRate },
+		set: func(c *Config, v string) error { c.Video.BitRate = v; return nil }},
+	{flag: "exposure", usage: "The exposure level of the drone camera (auto, 0, 1 or 2)", kind: stringKind,
+		get: func(c *Config) string { return c.Video.Exposure },
+		set: func(c *Config, v string) error { c.Video.Exposure = v; return nil }},
+	{flag: "ffmpeg", usage: "The path to the ffmpeg executable which decodes the video into frames", kind: stringKind,
+		get: func(c *Config) string { return c.Video.FFmpeg },
+		set: func(c *Config, v string) error { c.Video.FFmpeg = v; return nil }},
+	{flag: "frame-size", usage: "The size of the decoded video frames (ie. 480x360)", kind: stringKind,
+		get: func(c *Config) string { return fmt.Sprintf("%dx%d", c.Video.FrameWidth, c.Video.FrameHeight) },
+		set: func(c *Config, v string) error { return setSize(&c.Video.FrameWidth, &c.Video.FrameHeight, v) }},
+	{flag: "frame-rate", usage: "The number of video frames decoded per second", kind: intKind,
+		get: func(c *Config) string { return strconv.Itoa(c.Video.FrameRate) },
+		set: func(c *Config, v string) error { return setInt(&c.Video.FrameRate, v) }},
+	{flag: "verbose", short: "v", usage: "Enables verbose mode. You can enable extra verbosity by using -vv", kind: countKind,
+		get: func(c *Config) string { return strconv.Itoa(c.Log.Verbosity) },
+		set: func(c *Config, v string) error { return setInt(&c.Log.Verbosity, v) }},
+	{flag: "log-format", usage: "The format of the logs (text or json)", kind: stringKind,
+		get: func(c *Config) string { return c.Log.Format },
+		set: func(c *Config, v string) error { c.Log.Format = v; return nil }},
+	{flag: "log-file", usage: "Writes the logs into the specified file instead of the standard output", kind: stringKind,
+		get: func(c *Config) string { return c.Log.File },
+		set: func(c *Config, v string) error { c.Log.File = v; return nil }},
+}
+
+// RegisterFlags registers the configuration flags on the flag set. The defaults are only used for the help output.
+// Only the named flags are registered if any names are given (ie. drone-address), so that the small programs do not offer
+// the settings they never use. The settings which have not been registered cannot be set using the environment variables either.
+func RegisterFlags(flags *pflag.FlagSet, defaults Config, names ...string) {
+	flags.String(configFlag, "", "The path to a JSON config file. It can also be set using the "+EnvPrefix+"CONFIG environment variable")
+	for _, s := range settings {
+		if len(names) > 0 && !contains(names, s.flag) {
+			continue
+		}
+		value := s.get(&defaults)
+		switch s.kind {
+		case intKind:
+			v, _ := strconv.Atoi(value)
+			flags.IntP(s.flag, s.short, v, s.usage)
+		case boolKind:
+			v, _ := strconv.ParseBool(value)
+			flags.BoolP(s.flag, s.short, v, s.usage)
+		case durationKind:
+			v, _ := time.ParseDuration(value)
+			flags.DurationP(s.flag, s.short, v, s.usage)
+		case countKind:
+			flags.CountP(s.flag, s.short, s.usage)
+		default:
+			flags.StringP(s.flag, s.short, value, s.usage)
+		}
+	}
+}
+
+// Load builds and validates the configuration. The sources are applied in the following order, each overriding the previous one:
+// the defaults, the config file, the environment variables and the flags explicitly set on the command line.
+// The flag set must have been registered with RegisterFlags and parsed.
+func Load(flags *pflag.FlagSet, defaults Config) (Config, error) {
+	cfg := defaults
+	explicit := make(map[string]bool)
+
+	path := os.Getenv(EnvPrefix + "CONFIG")
+	if flags.Changed(configFlag) {
+		path, _ = flags.GetString(configFlag)
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg, explicit); err != nil {
+			return cfg, err
+		}
+	}
+
+	for _, s := range settings {
+		if flags.Lookup(s.flag) == nil {
+			continue
+		}
+		value, ok := os.LookupEnv(envName(s.flag))
+		if !ok {
+			continue
+		}
+		if err := s.set(&cfg, value); err != nil {
+			return cfg, fmt.Errorf("invalid %s environment variable: %s", envName(s.flag), err)
+		}
+		explicit[s.flag] = true
+	}
+
+	var err error
+	flags.Visit(func(f *pflag.Flag) {
+		for _, s := range settings {
+			if s.flag != f.Name || err != nil {
+				continue
+			}
+			if e := s.set(&cfg, f.Value.String()); e != nil {
+				err = fmt.Errorf("invalid --%s flag: %s", s.flag, e)
+			}
+			explicit[s.flag] = true
+		}
+	})
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.ActiveProfile, err = profile.Select(cfg.Profile, cfg.Profiles)
+	if err != nil {
+		return cfg, err
+	}
+	if p := cfg.ActiveProfile; p != nil {
+		// The profile only overrides the settings which have not been set explicitly
+		if !explicit["move"] {
+			cfg.Move = p.Move
+		}
+		if !explicit["max-moves"] {
+			cfg.MaxMoves = p.MaxMoves
+		}
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// loadFile overlays the settings in the config file on top of the configuration
+func loadFile(path string, cfg *Config, explicit map[string]bool) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("invalid config file %s: %s", path, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err == nil {
+		for key := range fields {
+			explicit[strings.Replace(key, "_", "-", -1)] = true
+		}
+	}
+	return nil
+}
+
+func contains(list []string, item string) bool {
+	for _, v := range list {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
+
+func envName(flag string) string {
+	return EnvPrefix + strings.ToUpper(strings.Replace(flag, "-", "_", -1))
+}
+
+func setInt(target *int, value string) error {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*target = v
+	return nil
+}
+
+func setBool(target *bool, value string) error {
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*target = v
+	return nil
+}
+
+func setDuration(target *Duration, value string) error {
+	v, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*target = Duration(v)
+	return nil
+}
+
+func setSize(width, height *int, value string) error {
+	parts := strings.SplitN(strings.ToLower(value), "x", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid size %q, expected WIDTHxHEIGHT", value)
+	}
+	if err := setInt(width, parts[0]); err != nil {
+		return fmt.Errorf("invalid size %q, expected WIDTHxHEIGHT", value)
+	}
+	if err := setInt(height, parts[1]); err != nil {
+		return fmt.Errorf("invalid size %q, expected WIDTHxHEIGHT", value)
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// swarmLocalPort is the first local port assigned to the swarm members which have not specified one
+const swarmLocalPort = 8890
+
+// setMembers parses the swarm members in name=ip[:command_port[:local_port]] format.
+// The groups of the members can only be set in the config file.
+func setMembers(target *[]Member, value string) error {
+	var members []Member
+	for i, item := range splitList(value) {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("invalid swarm member %q, expected name=ip[:command_port[:local_port]]", item)
+		}
+		m := Member{
+			Name:        parts[0],
+			CommandPort: 8889,
+			LocalPort:   swarmLocalPort + i,
+		}
+		address := strings.Split(parts[1], ":")
+		if len(address) > 3 || address[0] == "" {
+			return fmt.Errorf("invalid address %q for the swarm member %s", parts[1], m.Name)
+		}
+		m.Address = address[0]
+		if len(address) > 1 {
+			if err := setInt(&m.CommandPort, address[1]); err != nil {
+				return fmt.Errorf("invalid command port for the swarm member %s: %s", m.Name, err)
+			}
+		}
+		if len(address) > 2 {
+			if err := setInt(&m.LocalPort, address[2]); err != nil {
+				return fmt.Errorf("invalid local port for the swarm member %s: %s", m.Name, err)
+			}
+		}
+		members = append(members, m)
+	}
+	*target = members
+	return nil
+}
+
+func formatMembers(members []Member) string {
+	list := make([]string, 0, len(members))
+	for _, m := range members {
+		list = append(list, fmt.Sprintf("%s=%s:%d:%d", m.Name, m.Address, m.CommandPort, m.LocalPort))
+	}
+	return strings.Join(list, ",")
+}
+
+// setMarkers parses the marker actions in id=command[+command...] format
+func setMarkers(target *[]MarkerAction, value string) error {
+	var actions []MarkerAction
+	for _, item := range splitList(value) {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return fmt.Errorf("invalid marker action %q, expected id=command[+command...]", item)
+		}
+		m := MarkerAction{Commands: strings.Split(parts[1], "+")}
+		if err := setInt(&m.ID, parts[0]); err != nil {
+			return fmt.Errorf("invalid marker ID %q: %s", parts[0], err)
+		}
+		actions = append(actions, m)
+	}
+	*target = actions
+	return nil
+}
+
+func formatMarkers(actions []MarkerAction) string {
+	list := make([]string, 0, len(actions))
+	for _, m := range actions {
+		list = append(list, fmt.Sprintf("%d=%s", m.ID, strings.Join(m.Commands, "+")))
+	}
+	return strings.Join(list, ",")
+}
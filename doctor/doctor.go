@@ -0,0 +1,87 @@
+package doctor
+
+// Status is the outcome of a preflight check
+type Status int8
+
+const (
+	// Passed means the check has been successful
+	Passed Status = iota
+	// Warning means the check has found a problem which does not stop the drone from flying
+	Warning
+	// Failed means the drone must not take off until the problem has been fixed
+	Failed
+	// Skipped means the check could not run because a check it depends on has failed
+	Skipped
+)
+
+func (s Status) String() string {
+	switch s {
+	case Passed:
+		return "PASS"
+	case Warning:
+		return "WARN"
+	case Failed:
+		return "FAIL"
+	case Skipped:
+		return "SKIP"
+	default:
+		return "Unknown"
+	}
+}
+
+// Check is a single preflight check.
+// The checks only talk to the outside world through a System or a Drone, so they can be run against fakes.
+type Check interface {
+	// Name is the human readable name of the check
+	Name() string
+	// Run executes the check and returns its outcome along with a message for the user
+	Run() (Status, string)
+}
+
+// Result is the outcome of a check
+type Result struct {
+	Name    string
+	Status  Status
+	Message string
+}
+
+// Report is the outcome of all the checks in the order they have been run
+type Report []Result
+
+// Ready returns true if none of the checks has failed, so the drone is allowed to take off
+func (r Report) Ready() bool {
+	for _, result := range r {
+		if result.Status == Failed {
+			return false
+		}
+	}
+	return true
+}
+
+// Run executes the checks one after another in the specified order
+func Run(checks ...Check) Report {
+	report := make(Report, 0, len(checks))
+	for _, check := range checks {
+		status, message := check.Run()
+		report = append(report, Result{Name: check.Name(), Status: status, Message: message})
+	}
+	return report
+}
+
+// Func turns a function into a check
+func Func(name string, run func() (Status, string)) Check {
+	return funcCheck{name: name, run: run}
+}
+
+type funcCheck struct {
+	name string
+	run  func() (Status, string)
+}
+
+func (f funcCheck) Name() string {
+	return f.name
+}
+
+func (f funcCheck) Run() (Status, string) {
+	return f.run()
+}
@@ -0,0 +1,130 @@
+package doctor_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xitonix/gophobotics/doctor"
+	"github.com/xitonix/gophobotics/doctor/doctortest"
+)
+
+func statuses(report doctor.Report) map[string]doctor.Status {
+	result := make(map[string]doctor.Status, len(report))
+	for _, r := range report {
+		result[r.Name] = r.Status
+	}
+	return result
+}
+
+func TestEnvironmentChecks(t *testing.T) {
+	testCases := []struct {
+		title    string
+		system   doctortest.System
+		expected map[string]doctor.Status
+		ready    bool
+	}{
+		{
+			title:    "all good",
+			system:   doctortest.System{},
+			expected: map[string]doctor.Status{"Terminal": doctor.Passed, "Video Player": doctor.Passed, "Command Port": doctor.Passed},
+			ready:    true,
+		},
+		{
+			title:    "missing player is a warning",
+			system:   doctortest.System{Missing: map[string]bool{"mplayer": true}},
+			expected: map[string]doctor.Status{"Terminal": doctor.Passed, "Video Player": doctor.Warning, "Command Port": doctor.Passed},
+			ready:    true,
+		},
+		{
+			title:    "unsupported terminal",
+			system:   doctortest.System{Terminal: errors.New("not a terminal")},
+			expected: map[string]doctor.Status{"Terminal": doctor.Failed, "Video Player": doctor.Passed, "Command Port": doctor.Passed},
+			ready:    false,
+		},
+		{
+			title:    "busy port",
+			system:   doctortest.System{Busy: map[int]bool{8888: true}},
+			expected: map[string]doctor.Status{"Terminal": doctor.Passed, "Video Player": doctor.Passed, "Command Port": doctor.Failed},
+			ready:    false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			report := doctor.Run(doctor.Terminal(tc.system), doctor.Player(tc.system, "mplayer"), doctor.Port(tc.system, "Command", 8888))
+			actual := statuses(report)
+			for name, status := range tc.expected {
+				if actual[name] != status {
+					t.Errorf("Expected %s to be %s, got %s", name, status, actual[name])
+				}
+			}
+			if report.Ready() != tc.ready {
+				t.Errorf("Expected ready to be %v, got %v", tc.ready, report.Ready())
+			}
+		})
+	}
+}
+
+func TestDroneChecks(t *testing.T) {
+	testCases := []struct {
+		title    string
+		drone    *doctortest.Drone
+		expected [4]doctor.Status
+		ready    bool
+	}{
+		{
+			title:    "healthy drone",
+			drone:    &doctortest.Drone{Reported: doctor.DroneInfo{Battery: 80, WifiStrength: 90, Version: "01.04.92.01"}},
+			expected: [4]doctor.Status{doctor.Passed, doctor.Passed, doctor.Passed, doctor.Passed},
+			ready:    true,
+		},
+		{
+			title:    "no handshake",
+			drone:    &doctortest.Drone{HandshakeError: errors.New("timeout")},
+			expected: [4]doctor.Status{doctor.Failed, doctor.Skipped, doctor.Skipped, doctor.Skipped},
+			ready:    false,
+		},
+		{
+			title:    "battery below the minimum",
+			drone:    &doctortest.Drone{Reported: doctor.DroneInfo{Battery: 15, WifiStrength: 90, Version: "01.04.92.01"}},
+			expected: [4]doctor.Status{doctor.Passed, doctor.Failed, doctor.Passed, doctor.Passed},
+			ready:    false,
+		},
+		{
+			title:    "low battery reported by the drone",
+			drone:    &doctortest.Drone{Reported: doctor.DroneInfo{Battery: 30, BatteryLow: true, WifiStrength: 90, Version: "01.04.92.01"}},
+			expected: [4]doctor.Status{doctor.Passed, doctor.Warning, doctor.Passed, doctor.Passed},
+			ready:    true,
+		},
+		{
+			title:    "weak signal and unknown firmware",
+			drone:    &doctortest.Drone{Reported: doctor.DroneInfo{Battery: 80, WifiStrength: 20}},
+			expected: [4]doctor.Status{doctor.Passed, doctor.Passed, doctor.Warning, doctor.Warning},
+			ready:    true,
+		},
+		{
+			title:    "no state reported",
+			drone:    &doctortest.Drone{InfoError: errors.New("no state")},
+			expected: [4]doctor.Status{doctor.Passed, doctor.Failed, doctor.Failed, doctor.Failed},
+			ready:    false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			report := doctor.Run(doctor.DroneChecks(tc.drone, 20, 40)...)
+			if len(report) != len(tc.expected) {
+				t.Fatalf("Expected %d results, got %d", len(tc.expected), len(report))
+			}
+			for i, status := range tc.expected {
+				if report[i].Status != status {
+					t.Errorf("Expected %s to be %s, got %s: %s", report[i].Name, status, report[i].Status, report[i].Message)
+				}
+			}
+			if report.Ready() != tc.ready {
+				t.Errorf("Expected ready to be %v, got %v", tc.ready, report.Ready())
+			}
+			if tc.drone.HandshakeError == nil && tc.drone.InfoCalls != 1 {
+				t.Errorf("Expected the drone state to be read once, got %d", tc.drone.InfoCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package doctortest provides a fake system and a fake drone to test the preflight checks without a terminal or a drone.
+package doctortest
+
+import (
+	"errors"
+	"io"
+
+	"github.com/xitonix/gophobotics/doctor"
+)
+
+// System implements the doctor.System interface. The zero value has a working terminal, all the executables and all the ports.
+type System struct {
+	// Terminal is returned when the terminal is initialised
+	Terminal error
+	// Missing are the executables which cannot be found
+	Missing map[string]bool
+	// Busy are the UDP ports which are already in use
+	Busy map[int]bool
+}
+
+func (s System) InitTerminal() error {
+	return s.Terminal
+}
+
+func (s System) LookPath(file string) (string, error) {
+	if s.Missing[file] {
+		return "", errors.New("not found")
+	}
+	return "/usr/bin/" + file, nil
+}
+
+func (s System) ListenUDP(port int) (io.Closer, error) {
+	if s.Busy[port] {
+		return nil, errors.New("address already in use")
+	}
+	return nopCloser{}, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// Drone implements the doctor.Drone interface and records how it has been used
+type Drone struct {
+	// HandshakeError is returned by the handshake
+	HandshakeError error
+	// Reported is the information the drone reports
+	Reported doctor.DroneInfo
+	// InfoError is returned instead of the information
+	InfoError error
+	// InfoCalls is the number of times the information has been read
+	InfoCalls int
+	// Closed is true once the connection has been closed
+	Closed bool
+}
+
+func (d *Drone) Handshake() error {
+	return d.HandshakeError
+}
+
+func (d *Drone) Info() (doctor.DroneInfo, error) {
+	d.InfoCalls++
+	return d.Reported, d.InfoError
+}
+
+func (d *Drone) Close() {
+	d.Closed = true
+}
@@ -0,0 +1,155 @@
+package doctor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/SMerrony/tello"
+)
+
+// DroneInfo is what the drone reports about itself after the handshake
+type DroneInfo struct {
+	// Battery is the battery percentage
+	Battery int8
+	// BatteryLow is true if the drone reports that the battery is low
+	BatteryLow bool
+	// WifiStrength is the strength of the Wi-Fi signal (0-100)
+	WifiStrength uint8
+	// Version is the firmware version
+	Version string
+}
+
+// Drone is the drone the preflight checks are run against
+type Drone interface {
+	// Handshake connects to the drone and waits for it to respond
+	Handshake() error
+	// Info waits for the drone to report its state
+	Info() (DroneInfo, error)
+	// Close closes the connection to the drone
+	Close()
+}
+
+// DroneChecks returns the checks which talk to the drone. The handshake is only made once and
+// the rest of the checks are skipped if it fails. The battery check fails below the minimum battery percentage.
+func DroneChecks(drone Drone, minBattery int8, minWifiStrength uint8) []Check {
+	probe := &probe{drone: drone}
+	return []Check{
+		Func("Drone", probe.handshake),
+		Func("Battery", func() (Status, string) {
+			info, status, message := probe.info()
+			if status != Passed {
+				return status, message
+			}
+			if info.Battery < minBattery {
+				return Failed, fmt.Sprintf("%d%% is below the %d%% required to take off", info.Battery, minBattery)
+			}
+			if info.BatteryLow {
+				return Warning, fmt.Sprintf("%d%%, the drone reports that the battery is low", info.Battery)
+			}
+			return Passed, fmt.Sprintf("%d%%", info.Battery)
+		}),
+		Func("Firmware", func() (Status, string) {
+			info, status, message := probe.info()
+			if status != Passed {
+				return status, message
+			}
+			if info.Version == "" {
+				return Warning, "the drone has not reported its firmware version"
+			}
+			return Passed, info.Version
+		}),
+		Func("Wi-Fi", func() (Status, string) {
+			info, status, message := probe.info()
+			if status != Passed {
+				return status, message
+			}
+			if info.WifiStrength < minWifiStrength {
+				return Warning, fmt.Sprintf("the signal strength is %d%%, move closer to the drone", info.WifiStrength)
+			}
+			return Passed, fmt.Sprintf("the signal strength is %d%%", info.WifiStrength)
+		}),
+	}
+}
+
+// probe shares a single connection to the drone between the drone checks
+type probe struct {
+	drone     Drone
+	connected bool
+	once      sync.Once
+	data      DroneInfo
+	err       error
+}
+
+func (p *probe) handshake() (Status, string) {
+	if err := p.drone.Handshake(); err != nil {
+		return Failed, fmt.Sprintf("no response from the drone, make sure you are connected to its Wi-Fi: %s", err)
+	}
+	p.connected = true
+	return Passed, "the drone has responded to the connection request"
+}
+
+func (p *probe) info() (DroneInfo, Status, string) {
+	if !p.connected {
+		return DroneInfo{}, Skipped, "not connected to the drone"
+	}
+	p.once.Do(func() {
+		p.data, p.err = p.drone.Info()
+		p.drone.Close()
+	})
+	if p.err != nil {
+		return DroneInfo{}, Failed, p.err.Error()
+	}
+	return p.data, Passed, ""
+}
+
+// telloInfoTimeout is how long to wait for the drone to report its state after the handshake
+const telloInfoTimeout = 5 * time.Second
+
+// Tello returns a Tello drone which is reached at the specified address
+func Tello(address string, port, localPort int) Drone {
+	return &telloDrone{
+		address:   address,
+		port:      port,
+		localPort: localPort,
+		drone:     new(tello.Tello),
+	}
+}
+
+type telloDrone struct {
+	address         string
+	port, localPort int
+	drone           *tello.Tello
+}
+
+func (t *telloDrone) Handshake() error {
+	return t.drone.ControlConnect(t.address, t.port, t.localPort)
+}
+
+func (t *telloDrone) Info() (DroneInfo, error) {
+	deadline := time.Now().Add(telloInfoTimeout)
+	for time.Now().Before(deadline) {
+		fd := t.drone.GetFlightData()
+		if fd.Version == "" {
+			// The request is sent over UDP, so it's repeated until the version arrives
+			t.drone.GetVersion()
+		}
+		// The flight data is all zero until the first status message arrives
+		if fd.Version != "" && (fd.BatteryPercentage > 0 || fd.WifiStrength > 0) {
+			return DroneInfo{
+				Battery:      fd.BatteryPercentage,
+				BatteryLow:   fd.BatteryLow,
+				WifiStrength: fd.WifiStrength,
+				Version:      fd.Version,
+			}, nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return DroneInfo{}, fmt.Errorf("the drone has not reported its state within %s", telloInfoTimeout)
+}
+
+func (t *telloDrone) Close() {
+	if t.drone.ControlConnected() {
+		t.drone.ControlDisconnect()
+	}
+}
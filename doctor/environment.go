@@ -0,0 +1,78 @@
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strconv"
+
+	"github.com/nsf/termbox-go"
+)
+
+// System is the computer the environment checks are run on
+type System interface {
+	// InitTerminal puts the terminal into the raw mode and restores it
+	InitTerminal() error
+	// LookPath returns the full path of the executable
+	LookPath(file string) (string, error)
+	// ListenUDP binds to the local UDP port
+	ListenUDP(port int) (io.Closer, error)
+}
+
+// LocalSystem returns the computer the program is running on
+func LocalSystem() System {
+	return localSystem{}
+}
+
+type localSystem struct{}
+
+func (localSystem) InitTerminal() error {
+	if err := termbox.Init(); err != nil {
+		return err
+	}
+	termbox.Close()
+	return nil
+}
+
+func (localSystem) LookPath(file string) (string, error) {
+	return exec.LookPath(file)
+}
+
+func (localSystem) ListenUDP(port int) (io.Closer, error) {
+	return net.ListenPacket("udp", ":"+strconv.Itoa(port))
+}
+
+// Terminal checks that the terminal supports termbox, which the keyboard based sources rely on
+func Terminal(system System) Check {
+	return Func("Terminal", func() (Status, string) {
+		if err := system.InitTerminal(); err != nil {
+			return Failed, fmt.Sprintf("the keyboard cannot be read: %s", err)
+		}
+		return Passed, "termbox is supported"
+	})
+}
+
+// Player checks that the video player can be found in the PATH.
+// The video player is only needed to watch the video feed, so a missing player is only a warning.
+func Player(system System, player string) Check {
+	return Func("Video Player", func() (Status, string) {
+		path, err := system.LookPath(player)
+		if err != nil {
+			return Warning, fmt.Sprintf("%s is not installed, the video feed cannot be played", player)
+		}
+		return Passed, path
+	})
+}
+
+// Port checks that the local UDP port the drone talks to is not used by another program
+func Port(system System, name string, port int) Check {
+	return Func(fmt.Sprintf("%s Port", name), func() (Status, string) {
+		conn, err := system.ListenUDP(port)
+		if err != nil {
+			return Failed, fmt.Sprintf("UDP port %d is not available: %s", port, err)
+		}
+		_ = conn.Close()
+		return Passed, fmt.Sprintf("UDP port %d is free", port)
+	})
+}
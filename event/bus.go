@@ -0,0 +1,110 @@
+package event
+
+import (
+	"sync"
+)
+
+// subscriptionBufferSize is the number of events a subscriber can fall behind before the events are dropped
+const subscriptionBufferSize = 100
+
+// Bus is an in-process publish/subscribe event bus.
+// A nil Bus is valid and discards everything published to it.
+type Bus struct {
+	mux           sync.RWMutex
+	subscriptions map[*Subscription]struct{}
+}
+
+// NewBus creates a new event bus
+func NewBus() *Bus {
+	return &Bus{
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish delivers the event to all the interested subscribers.
+// Publish never blocks. The event will be dropped for the subscribers which are not keeping up.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	for s := range b.subscriptions {
+		if !s.wants(e.Type) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			s.dropped++
+		}
+	}
+}
+
+// Subscribe registers a new subscriber for the specified event types. All the events will be delivered if no type is specified.
+func (b *Bus) Subscribe(types ...Type) *Subscription {
+	s := &Subscription{
+		bus:    b,
+		events: make(chan Event, subscriptionBufferSize),
+	}
+	if len(types) > 0 {
+		s.types = make(map[Type]bool)
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+	if b == nil {
+		// Nothing will ever be published
+		return s
+	}
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.subscriptions[s] = struct{}{}
+	return s
+}
+
+func (b *Bus) unsubscribe(s *Subscription) {
+	if b == nil {
+		s.closer.Do(func() { close(s.events) })
+		return
+	}
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if _, ok := b.subscriptions[s]; ok {
+		delete(b.subscriptions, s)
+		s.closer.Do(func() { close(s.events) })
+	}
+}
+
+// Subscription receives the events a subscriber is interested in
+type Subscription struct {
+	bus     *Bus
+	types   map[Type]bool
+	events  chan Event
+	closer  sync.Once
+	dropped int
+}
+
+// Events returns the events published to the bus. The channel will be closed once the subscription is cancelled.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Cancel stops the delivery of the events and closes the Events channel
+func (s *Subscription) Cancel() {
+	s.bus.unsubscribe(s)
+}
+
+// Dropped returns the number of events which have been dropped because the subscriber was not keeping up
+func (s *Subscription) Dropped() int {
+	if s.bus == nil {
+		return 0
+	}
+	s.bus.mux.RLock()
+	defer s.bus.mux.RUnlock()
+	return s.dropped
+}
+
+func (s *Subscription) wants(t Type) bool {
+	return s.types == nil || s.types[t]
+}
@@ -0,0 +1,132 @@
+package event
+
+import (
+	"fmt"
+	"time"
+)
+
+// Type identifies the kind of an event
+type Type int8
+
+const (
+	// Unknown is the zero value of the event types
+	Unknown Type = iota
+	// CommandTriggered is published by the sources when a new command has been issued
+	CommandTriggered
+	// CommandExecuted is published by the robots when a command has been carried out
+	CommandExecuted
+	// CommandRejected is published by the robots when a command has been refused (ie. over the limits)
+	CommandRejected
+	// CommandFailed is published by the robots when a command could not be executed
+	CommandFailed
+
+	// TakeOff is published when the drone has taken off
+	TakeOff
+	// Landing is published when the drone is landing
+	Landing
+	// PalmLanding is published when the drone is landing on a palm
+	PalmLanding
+	// Flip is published when the drone has performed a flip
+	Flip
+	// Bounce is published when the drone bouncing mode has been toggled
+	Bounce
+
+	// BatteryLow is published once when the drone reports that the battery is low
+	BatteryLow
+	// ConnectionChanged is published when the health of the link to the robot changes
+	ConnectionChanged
+	// Disconnected is published when the link to the robot has been lost
+	Disconnected
+	// Failsafe is published when the failsafe action has been triggered
+	Failsafe
+	// MissionPad is published when the drone starts or stops flying over a mission pad
+	MissionPad
+	// MarkerDetected is published when a visual marker comes into sight of the camera
+	MarkerDetected
+	// VideoBitRate is published when the bit rate of the drone video has been changed
+	VideoBitRate
+	// Exposure is published when the exposure level of the drone camera has been changed
+	Exposure
+	// VideoMode is published when the video has been switched between the normal and the wide picture
+	VideoMode
+	// Recording is published when the recording of the video has been paused or resumed
+	Recording
+	// PhotoTaken is published when a camera routine has saved a photo
+	PhotoTaken
+)
+
+func (t Type) String() string {
+	switch t {
+	case CommandTriggered:
+		return "CommandTriggered"
+	case CommandExecuted:
+		return "CommandExecuted"
+	case CommandRejected:
+		return "CommandRejected"
+	case CommandFailed:
+		return "CommandFailed"
+	case TakeOff:
+		return "TakeOff"
+	case Landing:
+		return "Landing"
+	case PalmLanding:
+		return "PalmLanding"
+	case Flip:
+		return "Flip"
+	case Bounce:
+		return "Bounce"
+	case BatteryLow:
+		return "BatteryLow"
+	case ConnectionChanged:
+		return "ConnectionChanged"
+	case Disconnected:
+		return "Disconnected"
+	case Failsafe:
+		return "Failsafe"
+	case MissionPad:
+		return "MissionPad"
+	case MarkerDetected:
+		return "MarkerDetected"
+	case VideoBitRate:
+		return "VideoBitRate"
+	case Exposure:
+		return "Exposure"
+	case VideoMode:
+		return "VideoMode"
+	case Recording:
+		return "Recording"
+	case PhotoTaken:
+		return "PhotoTaken"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event is something notable which has happened in a robot or a source
+type Event struct {
+	// Type is the kind of the event
+	Type Type
+	// Publisher is the name of the component which has published the event (ie. tello, keyboard)
+	Publisher string
+	// Time is when the event happened
+	Time time.Time
+	// Data is the event specific payload (ie. the input.Command for the command events)
+	Data interface{}
+}
+
+// New creates a new event which happened now
+func New(eventType Type, publisher string, data interface{}) Event {
+	return Event{
+		Type:      eventType,
+		Publisher: publisher,
+		Time:      time.Now(),
+		Data:      data,
+	}
+}
+
+func (e Event) String() string {
+	if e.Data == nil {
+		return fmt.Sprintf("%s: %s", e.Publisher, e.Type)
+	}
+	return fmt.Sprintf("%s: %s %v", e.Publisher, e.Type, e.Data)
+}
@@ -0,0 +1,399 @@
+package fake
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// stateInterval is how often the fake SDK drone broadcasts its state
+	stateInterval = 100 * time.Millisecond
+	// padRange is the horizontal distance in centimetres within which the fake drone detects a mission pad
+	padRange = 60
+)
+
+// SDK is a fake Tello drone which speaks the SDK text protocol on a local UDP endpoint.
+//
+// It enters the SDK mode on "command", acknowledges the control commands, answers the read commands and keeps track of
+// a rough position, so the robots can be exercised without a real drone. Once in the SDK mode, it broadcasts its state
+// to the state port of the host which has sent the commands. Like a Tello EDU, it detects the mission pads placed using AddPad
+// once the detection has been turned on with "mon".
+//
+// The positions are in centimetres relative to where the drone has taken off. X points to the front, Y to the left and Z up.
+type SDK struct {
+	conn      *net.UDPConn
+	statePort int
+	done      chan interface{}
+	wg        sync.WaitGroup
+
+	mux      sync.Mutex
+	peer     *net.UDPAddr
+	commands []string
+	battery  int
+	flying   bool
+	x, y, z  float64
+	yaw      int
+	delay    time.Duration
+	silent   bool
+	pads     map[int][2]float64
+	mon      bool
+}
+
+// NewSDK starts a fake SDK drone on the specified UDP address (ie. 127.0.0.1:0 for a random port)
+// which broadcasts its state to the specified port of the host. Zero disables the state broadcast.
+func NewSDK(address string, statePort int) (*SDK, error) {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &SDK{
+		conn:      conn,
+		statePort: statePort,
+		done:      make(chan interface{}),
+		battery:   87,
+		pads:      make(map[int][2]float64),
+	}
+	s.wg.Add(2)
+	go s.listen()
+	go s.broadcast()
+	return s, nil
+}
+
+// Port returns the UDP port the fake drone is listening on
+func (s *SDK) Port() int {
+	return s.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// Commands returns all the commands the fake drone has received in order
+func (s *SDK) Commands() []string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// SetBattery sets the battery percentage the fake drone reports
+func (s *SDK) SetBattery(percentage int) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.battery = percentage
+}
+
+// SetDelay sets how long the fake drone takes to respond to each command
+func (s *SDK) SetDelay(delay time.Duration) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.delay = delay
+}
+
+// SetSilent stops the fake drone from responding and broadcasting its state, as if it was out of range
+func (s *SDK) SetSilent(silent bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.silent = silent
+}
+
+// AddPad places a mission pad on the floor. The pads face the same direction as the drone at take off.
+func (s *SDK) AddPad(id int, x, y float64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.pads[id] = [2]float64{x, y}
+}
+
+// Position returns the estimated position of the fake drone and the direction it is facing in degrees, measured clockwise
+func (s *SDK) Position() (x, y, z float64, yaw int) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.x, s.y, s.z, s.yaw
+}
+
+// Close stops the fake drone
+func (s *SDK) Close() error {
+	close(s.done)
+	err := s.conn.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *SDK) listen() {
+	defer s.wg.Done()
+	buf := make([]byte, 1024)
+	for {
+		n, peer, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		command := strings.TrimSpace(string(buf[:n]))
+		s.mux.Lock()
+		s.commands = append(s.commands, command)
+		silent, delay := s.silent, s.delay
+		s.mux.Unlock()
+		if silent {
+			continue
+		}
+		response := s.handle(command, peer)
+		go func() {
+			time.Sleep(delay)
+			_, _ = s.conn.WriteToUDP([]byte(response), peer)
+		}()
+	}
+}
+
+// handle carries out the command and returns the response
+func (s *SDK) handle(command string, peer *net.UDPAddr) string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return "error"
+	}
+	if fields[0] == "command" {
+		s.peer = peer
+		return "ok"
+	}
+	if s.peer == nil {
+		// The drone ignores everything until it has entered the SDK mode
+		return "error"
+	}
+
+	switch fields[0] {
+	case "battery?":
+		return strconv.Itoa(s.battery)
+	case "height?":
+		return fmt.Sprintf("%ddm", int(s.z/10))
+	case "speed?":
+		return "100.0"
+	case "time?":
+		return "0s"
+	case "wifi?":
+		return "90"
+	case "sdk?":
+		return "20"
+	case "sn?":
+		return "0TQZFAKE000000"
+	case "emergency":
+		s.flying, s.z = false, 0
+		return "ok"
+	case "takeoff":
+		if s.flying {
+			return "error"
+		}
+		s.flying, s.z = true, 80
+		return "ok"
+	case "land":
+		if !s.flying {
+			return "error"
+		}
+		s.flying, s.z = false, 0
+		return "ok"
+	case "stop", "streamon", "streamoff", "speed", "wifi", "ap", "mdirection":
+		return "ok"
+	case "mon":
+		s.mon = true
+		return "ok"
+	case "moff":
+		s.mon = false
+		return "ok"
+	}
+
+	if !s.flying {
+		return "error Not airborne"
+	}
+	switch fields[0] {
+	case "forward", "back", "left", "right", "up", "down", "cw", "ccw":
+		if len(fields) != 2 {
+			return "error"
+		}
+		value, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return "error"
+		}
+		return s.move(fields[0], value)
+	case "flip":
+		if len(fields) != 2 || !strings.Contains("fblr", fields[1]) || len(fields[1]) != 1 {
+			return "error"
+		}
+		return "ok"
+	case "go":
+		if len(fields) != 5 && len(fields) != 6 {
+			return "error"
+		}
+		v, ok := coordinates(fields[1:4])
+		if !ok {
+			return "error"
+		}
+		if len(fields) == 5 {
+			// The coordinates are relative to the drone
+			s.travel(v[0], -v[1])
+			s.z += v[2]
+			return "ok"
+		}
+		// The coordinates are relative to the pad
+		pad, ok := s.visiblePad(fields[5])
+		if !ok {
+			return "error No valid marker"
+		}
+		s.x, s.y, s.z = pad[0]+v[0], pad[1]+v[1], v[2]
+		return "ok"
+	case "jump":
+		if len(fields) != 8 {
+			return "error"
+		}
+		v, ok := coordinates(fields[1:4])
+		if !ok {
+			return "error"
+		}
+		yaw, err := strconv.Atoi(fields[5])
+		if err != nil {
+			return "error"
+		}
+		from, ok := s.visiblePad(fields[6])
+		if !ok {
+			return "error No valid marker"
+		}
+		s.x, s.y, s.z = from[0]+v[0], from[1]+v[1], v[2]
+		to, ok := s.visiblePad(fields[7])
+		if !ok {
+			return "error No valid marker"
+		}
+		s.x, s.y, s.yaw = to[0], to[1], ((yaw%360)+360)%360
+		return "ok"
+	case "rc":
+		return "ok"
+	default:
+		return "error"
+	}
+}
+
+func (s *SDK) move(direction string, value int) string {
+	switch direction {
+	case "cw", "ccw":
+		if value < 1 || value > 360 {
+			return "error"
+		}
+		if direction == "ccw" {
+			value = -value
+		}
+		s.yaw = ((s.yaw+value)%360 + 360) % 360
+		return "ok"
+	}
+	if value < 20 || value > 500 {
+		return "error"
+	}
+	d := float64(value)
+	switch direction {
+	case "forward":
+		s.travel(d, 0)
+	case "back":
+		s.travel(-d, 0)
+	case "right":
+		s.travel(0, d)
+	case "left":
+		s.travel(0, -d)
+	case "up":
+		s.z += d
+	case "down":
+		s.z = math.Max(s.z-d, 0)
+	}
+	return "ok"
+}
+
+// travel moves the drone relative to the direction it is facing
+func (s *SDK) travel(forward, right float64) {
+	// Turning clockwise moves the front of the drone from X towards -Y
+	rad := float64(s.yaw) * math.Pi / 180
+	s.x += forward*math.Cos(rad) - right*math.Sin(rad)
+	s.y += -forward*math.Sin(rad) - right*math.Cos(rad)
+}
+
+// visiblePad returns the position of the named pad (ie. m1) if the drone can see it. It must be called with the lock held.
+func (s *SDK) visiblePad(name string) ([2]float64, bool) {
+	id, err := strconv.Atoi(strings.TrimPrefix(name, "m"))
+	if err != nil || !s.mon {
+		return [2]float64{}, false
+	}
+	pad, ok := s.pads[id]
+	if !ok || math.Hypot(s.x-pad[0], s.y-pad[1]) > padRange {
+		return [2]float64{}, false
+	}
+	return pad, true
+}
+
+// detectedPad returns the ID of the closest pad the drone can see. It must be called with the lock held.
+func (s *SDK) detectedPad() (int, [2]float64) {
+	if !s.mon {
+		return -2, [2]float64{}
+	}
+	best, position, closest := -1, [2]float64{}, math.Inf(1)
+	if !s.flying {
+		return best, position
+	}
+	for id, pad := range s.pads {
+		if d := math.Hypot(s.x-pad[0], s.y-pad[1]); d <= padRange && d < closest {
+			best, position, closest = id, pad, d
+		}
+	}
+	return best, position
+}
+
+func coordinates(fields []string) ([3]float64, bool) {
+	var v [3]float64
+	for i, field := range fields {
+		value, err := strconv.Atoi(field)
+		if err != nil || value < -500 || value > 500 {
+			return v, false
+		}
+		v[i] = float64(value)
+	}
+	return v, true
+}
+
+func (s *SDK) broadcast() {
+	defer s.wg.Done()
+	if s.statePort <= 0 {
+		return
+	}
+	ticker := time.NewTicker(stateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.mux.Lock()
+			peer, silent, state := s.peer, s.silent, s.state()
+			s.mux.Unlock()
+			if peer == nil || silent {
+				continue
+			}
+			_, _ = s.conn.WriteToUDP([]byte(state), &net.UDPAddr{IP: peer.IP, Port: s.statePort})
+		}
+	}
+}
+
+// state formats the state string the way the drone does. It must be called with the lock held.
+func (s *SDK) state() string {
+	yaw := s.yaw
+	if yaw > 180 {
+		yaw -= 360
+	}
+	tof := 10
+	if s.flying {
+		tof = int(s.z)
+	}
+	id, pad := s.detectedPad()
+	var x, y, z int
+	if id > 0 {
+		x, y, z = int(s.x-pad[0]), int(s.y-pad[1]), int(s.z)
+	}
+	return fmt.Sprintf("mid:%d;x:%d;y:%d;z:%d;mpry:0,0,%d;pitch:0;roll:0;yaw:%d;vgx:0;vgy:0;vgz:0;templ:60;temph:62;tof:%d;h:%d;bat:%d;baro:100.12;time:0;agx:0.00;agy:0.00;agz:-1000.00;\r\n",
+		id, x, y, z, yaw, yaw, tof, int(s.z), s.battery)
+}
@@ -0,0 +1,111 @@
+package fake
+
+import (
+	"net"
+	"strings"
+	"sync"
+)
+
+// MessageID identifies a message of the Tello binary protocol
+type MessageID uint16
+
+const (
+	// StickMessage carries the stick positions. The client sends it periodically as a keep alive.
+	StickMessage MessageID = 0x0050
+	// TakeOffMessage asks the drone to take off
+	TakeOffMessage MessageID = 0x0054
+	// LandMessage asks the drone to land
+	LandMessage MessageID = 0x0055
+	// ThrowTakeOffMessage asks the drone to take off once it has been thrown
+	ThrowTakeOffMessage MessageID = 0x005d
+	// PalmLandMessage asks the drone to land on a palm
+	PalmLandMessage MessageID = 0x005e
+)
+
+// packetHeader is the first byte of all the packets except the connection handshake
+const packetHeader = 0xcc
+
+// Tello is a fake Tello drone listening on a local UDP endpoint.
+// It speaks just enough of the binary protocol to accept the connection handshake and records the messages it receives,
+// so the robots can be exercised without a real drone.
+type Tello struct {
+	conn *net.UDPConn
+	done chan interface{}
+
+	mux       sync.Mutex
+	connected bool
+	received  map[MessageID]int
+}
+
+// NewTello starts a fake drone on the specified UDP address (ie. 127.0.0.1:0 for a random port)
+func NewTello(address string) (*Tello, error) {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tello{
+		conn:     conn,
+		done:     make(chan interface{}),
+		received: make(map[MessageID]int),
+	}
+	go t.listen()
+	return t, nil
+}
+
+// Port returns the UDP port the fake drone is listening on
+func (t *Tello) Port() int {
+	return t.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// Connected returns true once a client has completed the connection handshake
+func (t *Tello) Connected() bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.connected
+}
+
+// Received returns the number of messages with the specified ID the fake drone has received
+func (t *Tello) Received(id MessageID) int {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.received[id]
+}
+
+// Close stops the fake drone
+func (t *Tello) Close() error {
+	err := t.conn.Close()
+	<-t.done
+	return err
+}
+
+func (t *Tello) listen() {
+	defer close(t.done)
+	buf := make([]byte, 2048)
+	for {
+		n, peer, err := t.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		packet := buf[:n]
+		if strings.HasPrefix(string(packet), "conn_req:") && n == 11 {
+			// The drone echoes the video port it has been asked to stream to
+			ack := append([]byte("conn_ack:"), packet[9:11]...)
+			_, _ = t.conn.WriteToUDP(ack, peer)
+			t.mux.Lock()
+			t.connected = true
+			t.mux.Unlock()
+			continue
+		}
+		if n < 7 || packet[0] != packetHeader {
+			continue
+		}
+		id := MessageID(uint16(packet[5]) | uint16(packet[6])<<8)
+		t.mux.Lock()
+		t.received[id]++
+		t.mux.Unlock()
+	}
+}
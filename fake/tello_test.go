@@ -0,0 +1,47 @@
+package fake_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	smerrony "github.com/SMerrony/tello"
+	"github.com/xitonix/gophobotics/fake"
+)
+
+func TestTelloRecordsTheMessages(t *testing.T) {
+	drone, err := fake.NewTello("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer drone.Close()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	localPort := conn.LocalAddr().(*net.UDPAddr).Port
+	_ = conn.Close()
+
+	client := new(smerrony.Tello)
+	if err := client.ControlConnect("127.0.0.1", drone.Port(), localPort); err != nil {
+		t.Fatal(err)
+	}
+	defer client.ControlDisconnect()
+	if !drone.Connected() {
+		t.Fatal("Expected the handshake to be completed")
+	}
+
+	client.ThrowTakeOff()
+	client.PalmLand()
+	deadline := time.Now().Add(3 * time.Second)
+	for drone.Received(fake.ThrowTakeOffMessage) == 0 || drone.Received(fake.PalmLandMessage) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the throw and the palm landing, got %d and %d",
+				drone.Received(fake.ThrowTakeOffMessage), drone.Received(fake.PalmLandMessage))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if n := drone.Received(fake.TakeOffMessage); n != 0 {
+		t.Errorf("Expected no regular take off, got %d", n)
+	}
+}
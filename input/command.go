@@ -1,11 +1,18 @@
 package input
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Command int8
 
 const (
 	None Command = iota
 	TakeOff
 	Land
+	ThrowTakeOff
+	PalmLand
 
 	Up
 	Down
@@ -22,6 +29,17 @@ const (
 	RightFlip
 	Bounce
 
+	ExposureUp
+	ExposureDown
+	AutoExposure
+	VideoNormal
+	VideoWide
+	BitRateUp
+	BitRateDown
+	AdaptiveBitRate
+	KeyFrame
+	ToggleRecording
+
 	Exit
 )
 
@@ -31,6 +49,10 @@ func (c Command) String() string {
 		return "Takeoff"
 	case Land:
 		return "Land"
+	case ThrowTakeOff:
+		return "ThrowTakeOff"
+	case PalmLand:
+		return "PalmLand"
 	case Up:
 		return "Up"
 	case Down:
@@ -63,6 +85,27 @@ func (c Command) String() string {
 
 	// End of Advanced Moves
 
+	case ExposureUp:
+		return "ExposureUp"
+	case ExposureDown:
+		return "ExposureDown"
+	case AutoExposure:
+		return "AutoExposure"
+	case VideoNormal:
+		return "VideoNormal"
+	case VideoWide:
+		return "VideoWide"
+	case BitRateUp:
+		return "BitRateUp"
+	case BitRateDown:
+		return "BitRateDown"
+	case AdaptiveBitRate:
+		return "AdaptiveBitRate"
+	case KeyFrame:
+		return "KeyFrame"
+	case ToggleRecording:
+		return "ToggleRecording"
+
 	case Exit:
 		return "Exit"
 	default:
@@ -75,5 +118,48 @@ func (c Command) IsRotation() bool {
 }
 
 func (c Command) IsLandOrTakeoff() bool {
-	return c == TakeOff || c == Land
+	return c.IsTakeOff() || c.IsLanding()
+}
+
+// IsTakeOff returns true for the commands which get the robot off the ground
+func (c Command) IsTakeOff() bool {
+	return c == TakeOff || c == ThrowTakeOff
+}
+
+// IsLanding returns true for the commands which bring the robot down
+func (c Command) IsLanding() bool {
+	return c == Land || c == PalmLand
+}
+
+// IsCamera returns true for the commands which change the camera or the video settings instead of moving the robot
+func (c Command) IsCamera() bool {
+	return c >= ExposureUp && c <= ToggleRecording
+}
+
+// IsSafety returns true for the commands which must always reach the robot
+func (c Command) IsSafety() bool {
+	return c == Land || c == Exit
+}
+
+// ParseCommand converts the name of a command (case insensitive) to a Command. It returns None for unknown names.
+func ParseCommand(name string) Command {
+	for c := None + 1; c <= Exit; c++ {
+		if strings.EqualFold(c.String(), name) {
+			return c
+		}
+	}
+	return None
+}
+
+// ParseCommands converts a list of command names to Commands
+func ParseCommands(names []string) ([]Command, error) {
+	commands := make([]Command, 0, len(names))
+	for _, name := range names {
+		c := ParseCommand(name)
+		if c == None {
+			return nil, fmt.Errorf("unknown command %q", name)
+		}
+		commands = append(commands, c)
+	}
+	return commands, nil
 }
@@ -2,7 +2,13 @@ package input
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"sync"
+
 	"github.com/nsf/termbox-go"
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/logging"
 )
 
 var keyMap = map[rune]Command{
@@ -14,23 +20,61 @@ var keyMap = map[rune]Command{
 	76:  RotateLeft,
 	114: RotateRight,
 	82:  RotateRight,
+	43:  ExposureUp,
+	61:  ExposureUp,
+	45:  ExposureDown,
+	95:  ExposureDown,
+	101: AutoExposure,
+	69:  AutoExposure,
+	110: VideoNormal,
+	78:  VideoNormal,
+	119: VideoWide,
+	87:  VideoWide,
+	93:  BitRateUp,
+	91:  BitRateDown,
+	97:  AdaptiveBitRate,
+	65:  AdaptiveBitRate,
+	107: KeyFrame,
+	75:  KeyFrame,
+	118: ToggleRecording,
+	86:  ToggleRecording,
+	116: ThrowTakeOff,
+	84:  ThrowTakeOff,
+	112: PalmLand,
+	80:  PalmLand,
 }
 
 // Keyboard implements the Source interface and provides keypress commands from the keyboard
 type Keyboard struct {
-	commands  chan Command
-	started   bool
-	verbosity Verbosity
+	commands chan Command
+	logger   *logging.Logger
+	bus      *event.Bus
+	bell     io.Writer
+
+	// started is true while the robot is in the air, so that SPACE knows whether to take off or to land
+	mux     sync.Mutex
+	started bool
 }
 
 // NewKeyboard creates a new Keyboard source
-func NewKeyboard(verbosity Verbosity) *Keyboard {
+func NewKeyboard(logger *logging.Logger) *Keyboard {
 	return &Keyboard{
-		commands:  make(chan Command),
-		verbosity: verbosity,
+		commands: make(chan Command),
+		logger:   logger.With(logging.Fields{"source": "keyboard"}),
+		bell:     os.Stdout,
 	}
 }
 
+// SetBell sets where the terminal bell is written to when a command has not been executed. The bell is silenced if it's nil.
+func (t *Keyboard) SetBell(bell io.Writer) {
+	t.bell = bell
+}
+
+// SetEventBus sets the bus the triggered commands are published to
+func (t *Keyboard) SetEventBus(bus *event.Bus) {
+	t.bus = bus
+}
+
 func (t *Keyboard) Commands() <-chan Command {
 	return t.commands
 }
@@ -45,11 +89,11 @@ func (t *Keyboard) Start() error {
 	for {
 		ev := termbox.PollEvent()
 
-		if t.verbosity == VeryVerbose {
-			fmt.Printf("KEY: %v, CH: %v, MODIFIER: %v, EVENT: %v\n", ev.Key, ev.Ch, ev.Mod, ev.Type)
-		}
+		t.logger.Debugf("KEY: %v, CH: %v, MODIFIER: %v, EVENT: %v", ev.Key, ev.Ch, ev.Mod, ev.Type)
 
 		if cmd := parseCharacter(ev.Ch); cmd != None {
+			t.track(cmd)
+			t.bus.Publish(event.New(event.CommandTriggered, "keyboard", cmd))
 			t.commands <- cmd
 			continue
 		}
@@ -58,10 +102,11 @@ func (t *Keyboard) Start() error {
 		if cmd == None {
 			continue
 		}
+		t.bus.Publish(event.New(event.CommandTriggered, "keyboard", cmd))
 		t.commands <- cmd
 
 		if cmd == Exit {
-			t.started = false
+			t.setStarted(false)
 			close(t.commands)
 			_ = termbox.Clear(0, 0)
 			return nil
@@ -69,6 +114,49 @@ func (t *Keyboard) Start() error {
 	}
 }
 
+// Acknowledge rings the terminal bell if a command has been rejected or has failed.
+// The superseded commands are only logged, since the pilot has already moved on (ie. pressed CTRL + C).
+// A take off or a landing which has not been executed leaves the robot where it was, so SPACE keeps its previous meaning.
+func (t *Keyboard) Acknowledge(result Result) {
+	if result.Outcome == Executed {
+		return
+	}
+	switch {
+	case result.Command.IsTakeOff():
+		t.setStarted(false)
+	case result.Command.IsLanding():
+		t.setStarted(true)
+	}
+	fields := logging.Fields{"command": result.Command, "outcome": result.Outcome}
+	if result.Reason != nil {
+		fields["reason"] = result.Reason
+	}
+	if result.Outcome == Superseded {
+		t.logger.Log(logging.Debug, fields, "Command %s", result.Outcome)
+		return
+	}
+	if t.bell != nil {
+		_, _ = fmt.Fprint(t.bell, "\a")
+	}
+	t.logger.Log(logging.Info, fields, "Command %s", result.Outcome)
+}
+
+// track keeps the meaning of SPACE in line with the take offs and the landings triggered by the other keys
+func (t *Keyboard) track(cmd Command) {
+	switch {
+	case cmd.IsTakeOff():
+		t.setStarted(true)
+	case cmd.IsLanding():
+		t.setStarted(false)
+	}
+}
+
+func (t *Keyboard) setStarted(started bool) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.started = started
+}
+
 func parseCharacter(ch rune) Command {
 	if cmd, ok := keyMap[ch]; ok {
 		return cmd
@@ -85,6 +173,10 @@ func printHelp(keyboard bool) {
 	fmt.Printf("    CTRL + C%s: Emergency landing and EXIT\n\n", mouse)
 
 	fmt.Printf("       SPACE: Takeoff/Land\n")
+	if keyboard {
+		fmt.Printf("           T: Throw Takeoff (throw the drone gently within 5 seconds)\n")
+		fmt.Printf("           P: Palm Land (hold your palm under the drone)\n")
+	}
 	fmt.Printf("    ARROW UP: Forward\n")
 	fmt.Printf("  ARROW DOWN: Backward\n")
 	fmt.Printf("  ARROW LEFT: Move left\n")
@@ -94,6 +186,16 @@ func printHelp(keyboard bool) {
 		fmt.Printf("           R: Rotate Right\n")
 		fmt.Printf("           U: UP\n")
 		fmt.Printf("           D: Down\n")
+		fmt.Printf("           +: Brighter Picture\n")
+		fmt.Printf("           -: Darker Picture\n")
+		fmt.Printf("           E: Automatic Exposure\n")
+		fmt.Printf("           N: Normal Video (4:3)\n")
+		fmt.Printf("           W: Wide Video (16:9)\n")
+		fmt.Printf("           ]: Higher Bit Rate\n")
+		fmt.Printf("           [: Lower Bit Rate\n")
+		fmt.Printf("           A: Adaptive Bit Rate\n")
+		fmt.Printf("           K: Key Frame\n")
+		fmt.Printf("           V: Start/Pause Recording\n")
 		fmt.Printf("          F1: Front Flip (BE CAREFUL)\n")
 		fmt.Printf("          F2: Back Flip (BE CAREFUL)\n")
 		fmt.Printf("          F3: Right Flip (BE CAREFUL)\n")
@@ -127,6 +229,7 @@ func (t *Keyboard) parseKey(key termbox.Key) Command {
 	// Here goes the advanced move mappings
 
 	case termbox.KeySpace:
+		t.mux.Lock()
 		if !t.started {
 			t.started = true
 			cmd = TakeOff
@@ -134,10 +237,11 @@ func (t *Keyboard) parseKey(key termbox.Key) Command {
 			t.started = false
 			cmd = Land
 		}
+		t.mux.Unlock()
 	}
 
-	if t.verbosity >= Verbose && cmd != None {
-		fmt.Printf("Command Triggered: %s\n", cmd)
+	if cmd != None {
+		t.logger.Log(logging.Info, logging.Fields{"command": cmd}, "Command Triggered: %s", cmd)
 	}
 	return cmd
 }
@@ -0,0 +1,49 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestKeyboardRingsTheBell(t *testing.T) {
+	testCases := []struct {
+		title   string
+		outcome Outcome
+		bell    string
+	}{
+		{title: "executed", outcome: Executed},
+		{title: "rejected", outcome: Rejected, bell: "\a"},
+		{title: "failed", outcome: Failed, bell: "\a"},
+		{title: "superseded", outcome: Superseded},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			bell := &bytes.Buffer{}
+			keyboard := NewKeyboard(newTestLogger())
+			keyboard.SetBell(bell)
+			keyboard.Acknowledge(Result{ID: 1, Command: Forward, Outcome: tc.outcome, Reason: errors.New("reason")})
+			if bell.String() != tc.bell {
+				t.Errorf("Expected the bell to get %q, got %q", tc.bell, bell.String())
+			}
+		})
+	}
+}
+
+func TestKeyboardKeepsTheMeaningOfSpace(t *testing.T) {
+	keyboard := NewKeyboard(newTestLogger())
+	keyboard.SetBell(nil)
+
+	keyboard.track(TakeOff)
+	keyboard.Acknowledge(Result{ID: 1, Command: TakeOff, Outcome: Rejected})
+	if keyboard.started {
+		t.Error("Expected SPACE to take off again after the take off has been rejected")
+	}
+	keyboard.track(TakeOff)
+	keyboard.track(Land)
+	keyboard.Acknowledge(Result{ID: 2, Command: Land, Outcome: Failed})
+	if !keyboard.started {
+		t.Error("Expected SPACE to land again after the landing has failed")
+	}
+}
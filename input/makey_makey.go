@@ -1,26 +1,32 @@
 package input
 
 import (
-	"fmt"
-
 	"github.com/nsf/termbox-go"
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/logging"
 )
 
 // MakeyMakey implements the Source interface and provides keypress commands from a MakeyMakey board
 type MakeyMakey struct {
-	commands  chan Command
-	started   bool
-	verbosity Verbosity
+	commands chan Command
+	started  bool
+	logger   *logging.Logger
+	bus      *event.Bus
 }
 
 // NewMakeyMakey creates a new MakeyMakey source
-func NewKMakeyMakey(verbosity Verbosity) *MakeyMakey {
+func NewKMakeyMakey(logger *logging.Logger) *MakeyMakey {
 	return &MakeyMakey{
-		commands:  make(chan Command),
-		verbosity: verbosity,
+		commands: make(chan Command),
+		logger:   logger.With(logging.Fields{"source": "makey-makey"}),
 	}
 }
 
+// SetEventBus sets the bus the triggered commands are published to
+func (t *MakeyMakey) SetEventBus(bus *event.Bus) {
+	t.bus = bus
+}
+
 func (t *MakeyMakey) Commands() <-chan Command {
 	return t.commands
 }
@@ -35,8 +41,9 @@ func (t *MakeyMakey) Start() error {
 	for {
 		ev := termbox.PollEvent()
 		cmd := t.parseKey(ev.Key)
-		if t.verbosity == VeryVerbose {
-			fmt.Printf("KEY: %v, CH: %v, MODIFIER: %v, EVENT: %v\n", ev.Key, ev.Ch, ev.Mod, ev.Type)
+		t.logger.Debugf("KEY: %v, CH: %v, MODIFIER: %v, EVENT: %v", ev.Key, ev.Ch, ev.Mod, ev.Type)
+		if cmd != None {
+			t.bus.Publish(event.New(event.CommandTriggered, "makey-makey", cmd))
 		}
 		t.commands <- cmd
 
@@ -71,9 +78,7 @@ func (t *MakeyMakey) parseKey(key termbox.Key) Command {
 			cmd = Land
 		}
 	}
-	if t.verbosity >= Verbose {
-		fmt.Printf("Command Triggered: %s\n", cmd)
-	}
+	t.logger.Log(logging.Info, logging.Fields{"command": cmd}, "Command Triggered: %s", cmd)
 
 	return cmd
 }
@@ -0,0 +1,202 @@
+package input
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/xitonix/gophobotics/logging"
+)
+
+var (
+	// ErrBlocked is the reason for dropping the commands which are not allowed
+	ErrBlocked = errors.New("the command is blocked")
+	// ErrRateLimited is the reason for dropping the commands which arrive too quickly
+	ErrRateLimited = errors.New("too many commands")
+	// ErrDebounced is the reason for dropping the repeated commands
+	ErrDebounced = errors.New("the command has been repeated too quickly")
+)
+
+// maxPendingResults is the number of forwarded commands the pipeline remembers the source ID of.
+// The robots do not acknowledge every command (ie. None), so the older IDs are forgotten rather than kept forever.
+const maxPendingResults = 1000
+
+// Middleware transforms a single command on its way from the source to the robot.
+// Returning an error drops the command and the error is reported back to the source as the reason.
+// The middlewares of a pipeline are called sequentially, so they do not need to be safe for concurrent use.
+type Middleware func(cmd Command) (Command, error)
+
+// RateLimit drops the commands which arrive less than the interval after the last forwarded command
+func RateLimit(interval time.Duration) Middleware {
+	var last time.Time
+	return func(cmd Command) (Command, error) {
+		now := time.Now()
+		if now.Sub(last) < interval {
+			return cmd, ErrRateLimited
+		}
+		last = now
+		return cmd, nil
+	}
+}
+
+// Debounce drops a command if it is the repeat of the previous command within the window (ie. the key repeats)
+func Debounce(window time.Duration) Middleware {
+	var (
+		previous Command
+		last     time.Time
+	)
+	return func(cmd Command) (Command, error) {
+		now := time.Now()
+		repeated := cmd == previous && now.Sub(last) < window
+		previous = cmd
+		last = now
+		if repeated {
+			return cmd, ErrDebounced
+		}
+		return cmd, nil
+	}
+}
+
+// Remap replaces the commands according to the mapping. The commands which are not in the mapping pass through untouched.
+func Remap(mapping map[Command]Command) Middleware {
+	return func(cmd Command) (Command, error) {
+		if mapped, ok := mapping[cmd]; ok {
+			return mapped, nil
+		}
+		return cmd, nil
+	}
+}
+
+// Block drops the specified commands (ie. no flips for beginners)
+func Block(commands ...Command) Middleware {
+	blocked := make(map[Command]bool)
+	for _, c := range commands {
+		blocked[c] = true
+	}
+	return func(cmd Command) (Command, error) {
+		if blocked[cmd] {
+			return cmd, ErrBlocked
+		}
+		return cmd, nil
+	}
+}
+
+// Allow drops all the commands except the specified ones
+func Allow(commands ...Command) Middleware {
+	allowed := make(map[Command]bool)
+	for _, c := range commands {
+		allowed[c] = true
+	}
+	return func(cmd Command) (Command, error) {
+		if !allowed[cmd] {
+			return cmd, ErrBlocked
+		}
+		return cmd, nil
+	}
+}
+
+// Mirror swaps the left and right commands
+func Mirror() Middleware {
+	return Remap(map[Command]Command{
+		Left:        Right,
+		Right:       Left,
+		RotateLeft:  RotateRight,
+		RotateRight: RotateLeft,
+		LeftFlip:    RightFlip,
+		RightFlip:   LeftFlip,
+	})
+}
+
+// Log writes every command passing through to the logger
+func Log(logger *logging.Logger) Middleware {
+	return func(cmd Command) (Command, error) {
+		logger.Log(logging.Info, logging.Fields{"command": cmd}, "Command Passed: %s", cmd)
+		return cmd, nil
+	}
+}
+
+// Pipeline implements the Source interface and runs the commands of another source through a chain of middlewares.
+// The safety commands (Land and Exit) skip the middlewares and always pass through.
+type Pipeline struct {
+	source       Source
+	acknowledger Acknowledger
+	middlewares  []Middleware
+	commands     chan Command
+	logger       *logging.Logger
+
+	mux      sync.Mutex
+	received uint64
+	sent     uint64
+	ids      map[uint64]uint64
+}
+
+// NewPipeline creates a new pipeline which applies the middlewares to the source commands in order
+func NewPipeline(source Source, logger *logging.Logger, middlewares ...Middleware) *Pipeline {
+	p := &Pipeline{
+		source:      source,
+		middlewares: middlewares,
+		commands:    make(chan Command),
+		logger:      logger.With(logging.Fields{"source": "pipeline"}),
+		ids:         make(map[uint64]uint64),
+	}
+	p.acknowledger, _ = source.(Acknowledger)
+	return p
+}
+
+func (p *Pipeline) Commands() <-chan Command {
+	return p.commands
+}
+
+// Start processes the source commands and blocks until the source is closed.
+// The source itself needs to be started separately.
+func (p *Pipeline) Start() error {
+	defer close(p.commands)
+	for cmd := range p.source.Commands() {
+		p.received++
+		out, err := p.apply(cmd)
+		if err != nil {
+			p.logger.Log(logging.Info, logging.Fields{"command": cmd, "reason": err}, "Command Dropped: %s", cmd)
+			if p.acknowledger != nil {
+				p.acknowledger.Acknowledge(Result{ID: p.received, Command: cmd, Outcome: Rejected, Reason: err})
+			}
+			continue
+		}
+		p.mux.Lock()
+		p.sent++
+		// The robot numbers the forwarded commands, which differ from the source's numbers once a command has been dropped
+		p.ids[p.sent] = p.received
+		if p.sent > maxPendingResults {
+			delete(p.ids, p.sent-maxPendingResults)
+		}
+		p.mux.Unlock()
+		p.commands <- out
+	}
+	return nil
+}
+
+// Acknowledge reports the result of a forwarded command back to the source using the source's own command ID
+func (p *Pipeline) Acknowledge(result Result) {
+	p.mux.Lock()
+	id, ok := p.ids[result.ID]
+	delete(p.ids, result.ID)
+	p.mux.Unlock()
+	if !ok || p.acknowledger == nil {
+		return
+	}
+	result.ID = id
+	p.acknowledger.Acknowledge(result)
+}
+
+func (p *Pipeline) apply(cmd Command) (Command, error) {
+	if cmd == None || cmd.IsSafety() {
+		return cmd, nil
+	}
+	var err error
+	for _, m := range p.middlewares {
+		cmd, err = m(cmd)
+		if err != nil {
+			return cmd, err
+		}
+	}
+	return cmd, nil
+}
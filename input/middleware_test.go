@@ -0,0 +1,177 @@
+package input
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xitonix/gophobotics/logging"
+)
+
+// recordingSource is a source which records the results of its commands
+type recordingSource struct {
+	commands chan Command
+
+	mux     sync.Mutex
+	results []Result
+}
+
+func newRecordingSource() *recordingSource {
+	return &recordingSource{commands: make(chan Command)}
+}
+
+func (r *recordingSource) Commands() <-chan Command {
+	return r.commands
+}
+
+func (r *recordingSource) Acknowledge(result Result) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.results = append(r.results, result)
+}
+
+func (r *recordingSource) acknowledged() []Result {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return append([]Result(nil), r.results...)
+}
+
+func newTestLogger() *logging.Logger {
+	return logging.New(logging.Debug, logging.NewMemory())
+}
+
+// receive reads the next command of the source or fails the test
+func receive(t *testing.T, source Source) Command {
+	t.Helper()
+	select {
+	case cmd := <-source.Commands():
+		return cmd
+	case <-time.After(time.Second):
+		t.Fatal("No command has been received")
+		return None
+	}
+}
+
+func TestPipelineApply(t *testing.T) {
+	testCases := []struct {
+		title       string
+		middlewares []Middleware
+		in          Command
+		expected    Command
+		dropped     bool
+	}{
+		{title: "no middlewares", in: Forward, expected: Forward},
+		{title: "mirror", middlewares: []Middleware{Mirror()}, in: RotateLeft, expected: RotateRight},
+		{title: "blocked", middlewares: []Middleware{Block(FrontFlip)}, in: FrontFlip, dropped: true},
+		{title: "not blocked", middlewares: []Middleware{Block(FrontFlip)}, in: BackFlip, expected: BackFlip},
+		{title: "in order", middlewares: []Middleware{Mirror(), Block(Right)}, in: Left, dropped: true},
+		{title: "remapped before allowed", middlewares: []Middleware{Remap(map[Command]Command{Up: Forward}), Allow(Forward)}, in: Up, expected: Forward},
+		{title: "landing skips the middlewares", middlewares: []Middleware{Allow(Forward)}, in: Land, expected: Land},
+		{title: "exit skips the middlewares", middlewares: []Middleware{Block(Exit)}, in: Exit, expected: Exit},
+		{title: "none skips the middlewares", middlewares: []Middleware{Allow(Forward)}, in: None, expected: None},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			p := NewPipeline(newRecordingSource(), newTestLogger(), tc.middlewares...)
+			out, err := p.apply(tc.in)
+			if tc.dropped {
+				if err == nil {
+					t.Errorf("Expected %s to be dropped, got %s", tc.in, out)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected %s to pass, got %s", tc.in, err)
+			}
+			if out != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, out)
+			}
+		})
+	}
+}
+
+func TestPipelineRejectsTheDroppedCommands(t *testing.T) {
+	source := newRecordingSource()
+	p := NewPipeline(source, newTestLogger(), Block(FrontFlip))
+	go func() { _ = p.Start() }()
+
+	source.commands <- Forward
+	if cmd := receive(t, p); cmd != Forward {
+		t.Fatalf("Expected Forward, got %s", cmd)
+	}
+	source.commands <- FrontFlip
+	source.commands <- Land
+	if cmd := receive(t, p); cmd != Land {
+		t.Fatalf("Expected Land, got %s", cmd)
+	}
+	close(source.commands)
+
+	results := source.acknowledged()
+	if len(results) != 1 {
+		t.Fatalf("Expected one result, got %v", results)
+	}
+	if results[0].ID != 2 || results[0].Command != FrontFlip || results[0].Outcome != Rejected || results[0].Reason != ErrBlocked {
+		t.Errorf("Expected the flip to be rejected as the second command, got %+v", results[0])
+	}
+}
+
+func TestPipelineAcknowledgeUsesTheSourceIDs(t *testing.T) {
+	source := newRecordingSource()
+	p := NewPipeline(source, newTestLogger(), Block(FrontFlip))
+	go func() { _ = p.Start() }()
+
+	// The source numbers the commands 1 to 4, the robot only sees 1, 3 and 4 as 1, 2 and 3
+	for _, cmd := range []Command{Forward, FrontFlip, Left, Right} {
+		source.commands <- cmd
+		if cmd != FrontFlip {
+			receive(t, p)
+		}
+	}
+	close(source.commands)
+
+	p.Acknowledge(Result{ID: 3, Command: Right, Outcome: Executed})
+	p.Acknowledge(Result{ID: 2, Command: Left, Outcome: Failed})
+	// A result which does not belong to a forwarded command, or which has already been acknowledged, is not passed on
+	p.Acknowledge(Result{ID: 3, Command: Right, Outcome: Executed})
+	p.Acknowledge(Result{ID: 9, Command: Up, Outcome: Executed})
+
+	expected := []Result{
+		{ID: 2, Command: FrontFlip, Outcome: Rejected, Reason: ErrBlocked},
+		{ID: 4, Command: Right, Outcome: Executed},
+		{ID: 3, Command: Left, Outcome: Failed},
+	}
+	results := source.acknowledged()
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, results)
+	}
+	for i, result := range results {
+		if result != expected[i] {
+			t.Errorf("Expected result %d to be %+v, got %+v", i, expected[i], result)
+		}
+	}
+}
+
+func TestPipelineForgetsTheUnacknowledgedCommands(t *testing.T) {
+	source := newRecordingSource()
+	p := NewPipeline(source, newTestLogger())
+	go func() { _ = p.Start() }()
+
+	for i := 0; i < maxPendingResults+10; i++ {
+		source.commands <- Forward
+		receive(t, p)
+	}
+	close(source.commands)
+
+	p.mux.Lock()
+	pending := len(p.ids)
+	p.mux.Unlock()
+	if pending != maxPendingResults {
+		t.Errorf("Expected %d pending results, got %d", maxPendingResults, pending)
+	}
+	p.Acknowledge(Result{ID: 1, Command: Forward, Outcome: Executed})
+	p.Acknowledge(Result{ID: maxPendingResults + 10, Command: Forward, Outcome: Executed})
+	results := source.acknowledged()
+	if len(results) != 1 || results[0].ID != maxPendingResults+10 {
+		t.Errorf("Expected only the latest command to be acknowledged, got %v", results)
+	}
+}
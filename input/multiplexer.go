@@ -0,0 +1,218 @@
+package input
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/xitonix/gophobotics/logging"
+)
+
+// ErrNotInControl is the reason for rejecting the commands of a source which does not have the control
+var ErrNotInControl = errors.New("another source is in control")
+
+// Multiplexer implements the Source interface and merges the commands of several sources.
+//
+// The commands of a source are only forwarded if no source with a higher priority has been active during the hold period,
+// or if the source has explicitly taken the control. Safety commands (Land and Exit) are always forwarded.
+// The Multiplexer remembers the origin of every forwarded command, so the acknowledgements are routed back to the right source.
+type Multiplexer struct {
+	commands chan Command
+	hold     time.Duration
+	logger   *logging.Logger
+	inputs   []*muxInput
+	done     chan interface{}
+	sendMux  sync.Mutex
+
+	mux        sync.Mutex
+	active     *muxInput
+	lastActive time.Time
+	owner      *muxInput
+	origins    map[uint64]origin
+	sent       uint64
+	closed     bool
+}
+
+type muxInput struct {
+	name         string
+	priority     int
+	source       Source
+	acknowledger Acknowledger
+	received     uint64
+}
+
+// origin is where a forwarded command has come from
+type origin struct {
+	input *muxInput
+	id    uint64
+}
+
+// NewMultiplexer creates a new multiplexing source.
+// A source with a higher priority keeps the control for the hold period after its last command.
+func NewMultiplexer(hold time.Duration, logger *logging.Logger) *Multiplexer {
+	return &Multiplexer{
+		commands: make(chan Command),
+		hold:     hold,
+		logger:   logger.With(logging.Fields{"source": "multiplexer"}),
+		done:     make(chan interface{}),
+		origins:  make(map[uint64]origin),
+	}
+}
+
+// Add registers a new source. The sources with higher priority values win.
+// All the sources need to be added before the multiplexer is started.
+func (m *Multiplexer) Add(name string, priority int, source Source) {
+	in := &muxInput{
+		name:     name,
+		priority: priority,
+		source:   source,
+	}
+	in.acknowledger, _ = source.(Acknowledger)
+	m.inputs = append(m.inputs, in)
+}
+
+func (m *Multiplexer) Commands() <-chan Command {
+	return m.commands
+}
+
+// Start merges the commands of all the sources and blocks until all of them are closed, or an Exit command is forwarded.
+// The sources themselves need to be started separately.
+func (m *Multiplexer) Start() error {
+	var wg sync.WaitGroup
+	for _, in := range m.inputs {
+		wg.Add(1)
+		go func(in *muxInput) {
+			defer wg.Done()
+			m.read(in)
+		}(in)
+	}
+	go func() {
+		wg.Wait()
+		m.close()
+	}()
+	<-m.done
+	return nil
+}
+
+// TakeControl gives the exclusive control to the named source until ReleaseControl is called.
+// It returns false if no source has been registered under that name.
+func (m *Multiplexer) TakeControl(name string) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	for _, in := range m.inputs {
+		if in.name == name {
+			m.owner = in
+			m.logger.Log(logging.Info, logging.Fields{"origin": name}, "Control taken over by %s", name)
+			return true
+		}
+	}
+	return false
+}
+
+// ReleaseControl removes the exclusive control and goes back to the priority based arbitration
+func (m *Multiplexer) ReleaseControl() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if m.owner != nil {
+		m.logger.Log(logging.Info, logging.Fields{"origin": m.owner.name}, "Control released by %s", m.owner.name)
+	}
+	m.owner = nil
+}
+
+// Origin returns the name of the source which has issued the forwarded command with the specified ID.
+// The origins are only kept until the commands are acknowledged by the robot.
+func (m *Multiplexer) Origin(id uint64) (string, bool) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	o, ok := m.origins[id]
+	if !ok {
+		return "", false
+	}
+	return o.input.name, true
+}
+
+// Acknowledge routes the result of a forwarded command back to the source it has come from
+func (m *Multiplexer) Acknowledge(result Result) {
+	m.mux.Lock()
+	o, ok := m.origins[result.ID]
+	delete(m.origins, result.ID)
+	m.mux.Unlock()
+	if !ok {
+		return
+	}
+	m.logger.Log(logging.Debug, logging.Fields{"origin": o.input.name, "command": result.Command, "outcome": result.Outcome}, "Command %s", result.Outcome)
+	if o.input.acknowledger != nil {
+		result.ID = o.id
+		o.input.acknowledger.Acknowledge(result)
+	}
+}
+
+func (m *Multiplexer) read(in *muxInput) {
+	for cmd := range in.source.Commands() {
+		in.received++
+		if cmd == None {
+			continue
+		}
+		if !m.forward(in, cmd) {
+			m.logger.Log(logging.Info, logging.Fields{"origin": in.name, "command": cmd}, "Command Dropped: %s", cmd)
+			if in.acknowledger != nil {
+				in.acknowledger.Acknowledge(Result{ID: in.received, Command: cmd, Outcome: Rejected, Reason: ErrNotInControl})
+			}
+		}
+	}
+}
+
+// forward sends the command to the robot if the source is allowed to. The sends are serialised,
+// so that the order of the recorded origins matches the order in which the robot reads the commands.
+func (m *Multiplexer) forward(in *muxInput, cmd Command) bool {
+	m.sendMux.Lock()
+	defer m.sendMux.Unlock()
+
+	m.mux.Lock()
+	if m.closed || !m.allowed(in, cmd) {
+		m.mux.Unlock()
+		return false
+	}
+	m.active = in
+	m.lastActive = time.Now()
+	m.sent++
+	m.origins[m.sent] = origin{input: in, id: in.received}
+	if cmd == Exit {
+		m.closed = true
+	}
+	m.mux.Unlock()
+
+	m.logger.Log(logging.Info, logging.Fields{"origin": in.name, "command": cmd}, "Command Forwarded: %s", cmd)
+	m.commands <- cmd
+	if cmd == Exit {
+		close(m.commands)
+		close(m.done)
+	}
+	return true
+}
+
+func (m *Multiplexer) allowed(in *muxInput, cmd Command) bool {
+	if cmd.IsSafety() {
+		return true
+	}
+	if m.owner != nil {
+		return m.owner == in
+	}
+	if m.active == nil || m.active == in || in.priority >= m.active.priority {
+		return true
+	}
+	return time.Since(m.lastActive) > m.hold
+}
+
+func (m *Multiplexer) close() {
+	m.sendMux.Lock()
+	defer m.sendMux.Unlock()
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if m.closed {
+		return
+	}
+	m.closed = true
+	close(m.commands)
+	close(m.done)
+}
@@ -0,0 +1,147 @@
+package input
+
+import (
+	"testing"
+	"time"
+)
+
+const testHold = 100 * time.Millisecond
+
+func startMultiplexer(t *testing.T) (*Multiplexer, *recordingSource, *recordingSource) {
+	t.Helper()
+	pilot, auto := newRecordingSource(), newRecordingSource()
+	m := NewMultiplexer(testHold, newTestLogger())
+	m.Add("pilot", 1, pilot)
+	m.Add("auto", 0, auto)
+	go func() { _ = m.Start() }()
+	t.Cleanup(func() {
+		close(pilot.commands)
+		close(auto.commands)
+	})
+	return m, pilot, auto
+}
+
+// forwarded sends the command from the source and fails the test unless the multiplexer forwards it
+func forwarded(t *testing.T, m *Multiplexer, from *recordingSource, cmd Command) {
+	t.Helper()
+	from.commands <- cmd
+	if received := receive(t, m); received != cmd {
+		t.Fatalf("Expected %s to be forwarded, got %s", cmd, received)
+	}
+}
+
+// rejected sends the command from the source and fails the test unless the source is told it's not in control
+func rejected(t *testing.T, from *recordingSource, cmd Command) {
+	t.Helper()
+	before := len(from.acknowledged())
+	from.commands <- cmd
+	deadline := time.Now().Add(time.Second)
+	for len(from.acknowledged()) == before {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %s to be rejected", cmd)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	result := from.acknowledged()[before]
+	if result.Command != cmd || result.Outcome != Rejected || result.Reason != ErrNotInControl {
+		t.Errorf("Expected %s to be rejected, got %+v", cmd, result)
+	}
+}
+
+func TestMultiplexerPriority(t *testing.T) {
+	m, pilot, auto := startMultiplexer(t)
+
+	forwarded(t, m, auto, Forward)
+	// A higher priority takes over straight away
+	forwarded(t, m, pilot, Left)
+	// A lower priority waits for the hold period
+	rejected(t, auto, Right)
+	forwarded(t, m, pilot, Right)
+	time.Sleep(2 * testHold)
+	forwarded(t, m, auto, Backward)
+	// The source which is already active keeps the control
+	forwarded(t, m, auto, Up)
+}
+
+func TestMultiplexerForwardsTheSafetyCommands(t *testing.T) {
+	m, pilot, auto := startMultiplexer(t)
+
+	forwarded(t, m, pilot, Forward)
+	forwarded(t, m, auto, Land)
+	if !m.TakeControl("pilot") {
+		t.Fatal("Expected the pilot to take the control")
+	}
+	forwarded(t, m, auto, Land)
+}
+
+func TestMultiplexerTakeControl(t *testing.T) {
+	m, pilot, auto := startMultiplexer(t)
+
+	if m.TakeControl("nobody") {
+		t.Error("Expected an unknown source not to take the control")
+	}
+	if !m.TakeControl("auto") {
+		t.Fatal("Expected the lower priority source to take the control")
+	}
+	rejected(t, pilot, Left)
+	forwarded(t, m, auto, Forward)
+
+	m.ReleaseControl()
+	forwarded(t, m, pilot, Left)
+}
+
+func TestMultiplexerRoutesTheResults(t *testing.T) {
+	m, pilot, auto := startMultiplexer(t)
+
+	// The robot numbers the commands 1, 2 and 3, while each source numbers its own commands
+	forwarded(t, m, auto, Forward)
+	forwarded(t, m, pilot, Left)
+	rejected(t, auto, Right)
+	forwarded(t, m, pilot, Up)
+
+	if origin, ok := m.Origin(2); !ok || origin != "pilot" {
+		t.Errorf("Expected the second command to come from the pilot, got %q", origin)
+	}
+	m.Acknowledge(Result{ID: 1, Command: Forward, Outcome: Executed})
+	m.Acknowledge(Result{ID: 3, Command: Up, Outcome: Failed})
+	m.Acknowledge(Result{ID: 2, Command: Left, Outcome: Executed})
+	if _, ok := m.Origin(2); ok {
+		t.Error("Expected the origin to be forgotten once the command has been acknowledged")
+	}
+
+	// The rejection is acknowledged straight away, before the robot acknowledges anything
+	expectResults(t, "auto", auto.acknowledged(), []Result{
+		{ID: 2, Command: Right, Outcome: Rejected, Reason: ErrNotInControl},
+		{ID: 1, Command: Forward, Outcome: Executed},
+	})
+	expectResults(t, "pilot", pilot.acknowledged(), []Result{
+		{ID: 2, Command: Up, Outcome: Failed},
+		{ID: 1, Command: Left, Outcome: Executed},
+	})
+}
+
+func TestMultiplexerClosesOnExit(t *testing.T) {
+	m, pilot, _ := startMultiplexer(t)
+
+	forwarded(t, m, pilot, Exit)
+	select {
+	case _, open := <-m.Commands():
+		if open {
+			t.Error("Expected the commands channel to be closed after Exit")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The commands channel has not been closed")
+	}
+}
+
+func expectResults(t *testing.T, source string, actual, expected []Result) {
+	t.Helper()
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %s to get %v, got %v", source, expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Expected result %d of %s to be %+v, got %+v", i, source, expected[i], actual[i])
+		}
+	}
+}
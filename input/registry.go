@@ -0,0 +1,91 @@
+package input
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/logging"
+)
+
+// Runner is a Source which only produces commands once it has been started.
+// Start blocks until the source is closed.
+type Runner interface {
+	Source
+	Start() error
+}
+
+// Options are the settings the sources are created with
+type Options struct {
+	// Logger is the logger of the source
+	Logger *logging.Logger
+	// Bus is the event bus the source publishes its events to
+	Bus *event.Bus
+}
+
+// Factory creates a new source
+type Factory func(options Options) (Runner, error)
+
+// Registration describes a registered source
+type Registration struct {
+	Name        string
+	Description string
+	factory     Factory
+}
+
+var (
+	registryMux sync.Mutex
+	registry    = make(map[string]Registration)
+)
+
+func init() {
+	Register("keyboard", "The arrow keys and the keyboard shortcuts", func(options Options) (Runner, error) {
+		k := NewKeyboard(options.Logger)
+		k.SetEventBus(options.Bus)
+		return k, nil
+	})
+	Register("makey-makey", "A Makey Makey board wired to the arrow keys", func(options Options) (Runner, error) {
+		m := NewKMakeyMakey(options.Logger)
+		m.SetEventBus(options.Bus)
+		return m, nil
+	})
+}
+
+// Register makes a source available by name. Registering a source under an existing name replaces it.
+func Register(name, description string, factory Factory) {
+	registryMux.Lock()
+	defer registryMux.Unlock()
+	registry[name] = Registration{Name: name, Description: description, factory: factory}
+}
+
+// IsRegistered returns true if a source has been registered under the name
+func IsRegistered(name string) bool {
+	registryMux.Lock()
+	defer registryMux.Unlock()
+	_, ok := registry[name]
+	return ok
+}
+
+// Registered returns all the registered sources sorted by name
+func Registered() []Registration {
+	registryMux.Lock()
+	defer registryMux.Unlock()
+	list := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// New creates the source registered under the name
+func New(name string, options Options) (Runner, error) {
+	registryMux.Lock()
+	r, ok := registry[name]
+	registryMux.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown source %q", name)
+	}
+	return r.factory(options)
+}
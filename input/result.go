@@ -0,0 +1,49 @@
+package input
+
+// Outcome is what happened to a command after it had been dispatched to a robot
+type Outcome int8
+
+const (
+	// Executed means the robot has carried out the command
+	Executed Outcome = iota
+	// Rejected means the robot refused to execute the command (ie. over the limits or not supported)
+	Rejected
+	// Failed means the robot tried to execute the command, but it failed
+	Failed
+	// Superseded means the command was dropped in favour of a later command
+	Superseded
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Executed:
+		return "Executed"
+	case Rejected:
+		return "Rejected"
+	case Failed:
+		return "Failed"
+	case Superseded:
+		return "Superseded"
+	default:
+		return "Unknown"
+	}
+}
+
+// Result reports the outcome of a dispatched command back to its source
+type Result struct {
+	// ID is the sequence number of the command within its source, starting from 1.
+	// The robots number the commands in the order they are read from the Commands channel.
+	ID uint64
+	// Command is the command the result belongs to
+	Command Command
+	// Outcome is what happened to the command
+	Outcome Outcome
+	// Reason explains why the command was not executed. It is nil for executed commands.
+	Reason error
+}
+
+// Acknowledger is an optional interface that the sources can implement to learn about the results of their commands.
+// The robots call Acknowledge once for every command they read from the source, so it must not block.
+type Acknowledger interface {
+	Acknowledge(result Result)
+}
@@ -0,0 +1,58 @@
+package logging
+
+import "strings"
+
+// Level is the severity of a log entry
+type Level int8
+
+const (
+	// Debug is for the detailed diagnostics information (ie. raw key presses)
+	Debug Level = iota
+	// Info is for the normal operational messages (ie. executed commands)
+	Info
+	// Warn is for the unexpected situations which the program can recover from (ie. low battery)
+	Warn
+	// Error is for the failures
+	Error
+)
+
+func (l Level) String() string {
+	switch l {
+	case Debug:
+		return "DEBUG"
+	case Info:
+		return "INFO"
+	case Warn:
+		return "WARN"
+	case Error:
+		return "ERROR"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ParseVerbosity maps the number of -v flags onto a log level.
+// No -v only logs the warnings and errors, -v adds the info messages and -vv enables everything.
+func ParseVerbosity(v int) Level {
+	if v >= 2 {
+		return Debug
+	}
+	if v == 1 {
+		return Info
+	}
+	return Warn
+}
+
+// ParseLevel converts the name of a log level to a Level. It falls back to Info.
+func ParseLevel(name string) Level {
+	switch strings.ToLower(name) {
+	case "debug":
+		return Debug
+	case "warn", "warning":
+		return Warn
+	case "error":
+		return Error
+	default:
+		return Info
+	}
+}
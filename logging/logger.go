@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// Fields are the key/value pairs attached to the log entries (ie. command, source, robot)
+type Fields map[string]interface{}
+
+// Logger writes levelled, structured log entries to one or more outputs.
+// A nil Logger is valid and discards everything.
+type Logger struct {
+	level   Level
+	outputs []Output
+	fields  Fields
+}
+
+// New creates a new logger which writes the entries at the specified level or above to the outputs
+func New(level Level, outputs ...Output) *Logger {
+	return &Logger{
+		level:   level,
+		outputs: outputs,
+	}
+}
+
+// NewStdout creates a new logger which writes the entries in text format to standard output
+func NewStdout(level Level) *Logger {
+	return New(level, NewOutput(os.Stdout, Text))
+}
+
+// Open creates a logger which writes to the specified file, or to standard output if the path is empty.
+// The returned closer must be called before the program exits.
+func Open(level Level, format Format, path string) (*Logger, io.Closer, error) {
+	if path == "" {
+		return New(level, NewOutput(os.Stdout, format)), ioutil.NopCloser(nil), nil
+	}
+	output, closer, err := OpenFile(path, format)
+	if err != nil {
+		return nil, nil, err
+	}
+	return New(level, output), closer, nil
+}
+
+// With returns a child logger which attaches the fields to every entry on top of the parent's fields
+func (l *Logger) With(fields Fields) *Logger {
+	if l == nil {
+		return nil
+	}
+	merged := make(Fields, len(l.fields)+len(fields))
+	for key, value := range l.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return &Logger{
+		level:   l.level,
+		outputs: l.outputs,
+		fields:  merged,
+	}
+}
+
+// Enabled returns true if the entries at the specified level will be written
+func (l *Logger) Enabled(level Level) bool {
+	return l != nil && level >= l.level
+}
+
+// Debugf writes a debug entry
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.log(Debug, nil, format, args...)
+}
+
+// Infof writes an info entry
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.log(Info, nil, format, args...)
+}
+
+// Warnf writes a warning entry
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.log(Warn, nil, format, args...)
+}
+
+// Errorf writes an error entry
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.log(Error, nil, format, args...)
+}
+
+// Log writes an entry with extra fields which are only attached to this entry
+func (l *Logger) Log(level Level, fields Fields, format string, args ...interface{}) {
+	l.log(level, fields, format, args...)
+}
+
+func (l *Logger) log(level Level, fields Fields, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	entry := Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: fmt.Sprintf(format, args...),
+		Fields:  l.fields,
+	}
+	if len(fields) > 0 {
+		entry.Fields = make(Fields, len(l.fields)+len(fields))
+		for key, value := range l.fields {
+			entry.Fields[key] = value
+		}
+		for key, value := range fields {
+			entry.Fields[key] = value
+		}
+	}
+	for _, output := range l.outputs {
+		_ = output.Write(entry)
+	}
+}
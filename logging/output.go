@@ -0,0 +1,147 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format is the encoding of the log entries
+type Format int8
+
+const (
+	// Text writes the log entries in a human readable format
+	Text Format = iota
+	// JSON writes each log entry as a JSON object on a separate line
+	JSON
+)
+
+// ParseFormat converts the name of a format to a Format. It falls back to Text.
+func ParseFormat(name string) Format {
+	if strings.ToLower(name) == "json" {
+		return JSON
+	}
+	return Text
+}
+
+// Entry is a single log record
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Fields  Fields
+}
+
+// Output is where the log entries are written to
+type Output interface {
+	Write(entry Entry) error
+}
+
+// NewOutput creates an output which encodes the entries in the specified format and writes them to w
+func NewOutput(w io.Writer, format Format) Output {
+	if format == JSON {
+		return &jsonOutput{writer: w}
+	}
+	return &textOutput{writer: w}
+}
+
+// OpenFile creates an output which appends the log entries to the specified file.
+// The returned closer must be called once the output is not needed anymore.
+func OpenFile(path string, format Format) (Output, io.Closer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewOutput(file, format), file, nil
+}
+
+type textOutput struct {
+	mux    sync.Mutex
+	writer io.Writer
+}
+
+func (o *textOutput) Write(entry Entry) error {
+	var b strings.Builder
+	b.WriteString(entry.Time.Format("15:04:05.000"))
+	b.WriteString(" ")
+	b.WriteString(fmt.Sprintf("%-5s", entry.Level))
+	b.WriteString(" ")
+	b.WriteString(entry.Message)
+	keys := make([]string, 0, len(entry.Fields))
+	for key := range entry.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		b.WriteString(fmt.Sprintf(" %s=%v", key, entry.Fields[key]))
+	}
+	// The terminal might be in raw mode while the sources are active
+	b.WriteString("\r\n")
+
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	_, err := io.WriteString(o.writer, b.String())
+	return err
+}
+
+type jsonOutput struct {
+	mux    sync.Mutex
+	writer io.Writer
+}
+
+func (o *jsonOutput) Write(entry Entry) error {
+	record := make(map[string]interface{}, len(entry.Fields)+3)
+	for key, value := range entry.Fields {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		} else if s, ok := value.(fmt.Stringer); ok {
+			value = s.String()
+		}
+		record[key] = value
+	}
+	record["time"] = entry.Time.Format(time.RFC3339Nano)
+	record["level"] = entry.Level.String()
+	record["message"] = entry.Message
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	_, err = o.writer.Write(append(data, '\n'))
+	return err
+}
+
+// Memory is an output which keeps the log entries in memory. It is mostly useful for capturing the logs in tests.
+type Memory struct {
+	mux     sync.Mutex
+	entries []Entry
+}
+
+// NewMemory creates a new in-memory output
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Write stores the entry
+func (m *Memory) Write(entry Entry) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+// Entries returns a copy of the captured entries
+func (m *Memory) Entries() []Entry {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	entries := make([]Entry, len(m.entries))
+	copy(entries, m.entries)
+	return entries
+}
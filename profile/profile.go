@@ -0,0 +1,174 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/robot"
+)
+
+// Profile is a named set of flight settings suitable for a level of experience
+type Profile struct {
+	// Name is the unique name of the profile
+	Name string `json:"name"`
+	// Move is the speed of the drone (0-100) passed to the robot
+	Move int `json:"move"`
+	// MaxMoves is the size of the geofence in number of moves in each direction. Zero means no limit.
+	MaxMoves int `json:"max_moves"`
+	// MaxHeight is the maximum height in decimetres. Zero means no limit.
+	MaxHeight int `json:"max_height"`
+	// AllowFlips enables the flip and bounce commands
+	AllowFlips bool `json:"allow_flips"`
+	// Commands is the list of allowed commands. All the commands are allowed if it's empty.
+	Commands []string `json:"commands,omitempty"`
+	// MinTakeOffBattery is the minimum battery percentage required to take off
+	MinTakeOffBattery int `json:"min_takeoff_battery"`
+	// LandBattery is the battery percentage below which the drone lands automatically
+	LandBattery int `json:"land_battery"`
+}
+
+var builtIn = []Profile{
+	{
+		Name:              "beginner",
+		Move:              20,
+		MaxMoves:          3,
+		MaxHeight:         15,
+		Commands:          []string{"TakeOff", "Land", "Up", "Down", "Forward", "Backward", "Left", "Right", "RotateLeft", "RotateRight"},
+		MinTakeOffBattery: 50,
+		LandBattery:       20,
+	},
+	{
+		Name:              "intermediate",
+		Move:              30,
+		MaxMoves:          6,
+		MaxHeight:         30,
+		MinTakeOffBattery: 30,
+		LandBattery:       15,
+	},
+	{
+		Name:              "expert",
+		Move:              60,
+		AllowFlips:        true,
+		MinTakeOffBattery: 20,
+		LandBattery:       10,
+	},
+}
+
+// BuiltIn returns the profiles which are available without a profile file
+func BuiltIn() []Profile {
+	profiles := make([]Profile, len(builtIn))
+	copy(profiles, builtIn)
+	return profiles
+}
+
+// Load reads the profiles from a JSON file containing an array of profiles
+func Load(path string) ([]Profile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var profiles []Profile
+	if err := json.NewDecoder(file).Decode(&profiles); err != nil {
+		return nil, fmt.Errorf("invalid profile file %s: %s", path, err)
+	}
+	for _, p := range profiles {
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return profiles, nil
+}
+
+// Select finds a profile by its name (case insensitive). The profiles in the file, if specified, take precedence over the built-in ones.
+// It returns nil if the name is empty.
+func Select(name, path string) (*Profile, error) {
+	if name == "" {
+		return nil, nil
+	}
+	profiles := BuiltIn()
+	if path != "" {
+		loaded, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(loaded, profiles...)
+	}
+	for _, p := range profiles {
+		if strings.EqualFold(p.Name, name) {
+			return &p, nil
+		}
+	}
+	names := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown profile %q, available profiles: %s", name, strings.Join(names, ", "))
+}
+
+// Validate checks if the profile settings are within the acceptable ranges
+func (p Profile) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("the profile name cannot be empty")
+	}
+	if p.Move <= 0 || p.Move > 100 {
+		return fmt.Errorf("profile %s: move must be between 1 and 100", p.Name)
+	}
+	if p.MaxMoves < 0 || p.MaxHeight < 0 {
+		return fmt.Errorf("profile %s: the limits cannot be negative", p.Name)
+	}
+	if p.MinTakeOffBattery < 0 || p.MinTakeOffBattery > 100 || p.LandBattery < 0 || p.LandBattery > 100 {
+		return fmt.Errorf("profile %s: the battery thresholds must be between 0 and 100", p.Name)
+	}
+	if _, err := input.ParseCommands(p.Commands); err != nil {
+		return fmt.Errorf("profile %s: %s", p.Name, err)
+	}
+	return nil
+}
+
+// Limits returns the safety limits the robot needs to enforce
+func (p Profile) Limits() robot.Limits {
+	return robot.Limits{
+		MaxHeight:         int16(p.MaxHeight),
+		MinTakeOffBattery: int8(p.MinTakeOffBattery),
+		LandBattery:       int8(p.LandBattery),
+	}
+}
+
+// Middlewares returns the input middlewares which block the commands the profile does not allow
+func (p Profile) Middlewares() []input.Middleware {
+	var middlewares []input.Middleware
+	if len(p.Commands) > 0 {
+		// The profile has been validated, so all the names are known
+		allowed, _ := input.ParseCommands(p.Commands)
+		middlewares = append(middlewares, input.Allow(allowed...))
+	}
+	if !p.AllowFlips {
+		middlewares = append(middlewares, input.Block(input.FrontFlip, input.BackFlip, input.LeftFlip, input.RightFlip, input.Bounce))
+	}
+	return middlewares
+}
+
+func (p Profile) String() string {
+	limit := func(v int, unit string) string {
+		if v <= 0 {
+			return "unlimited"
+		}
+		return fmt.Sprintf("%d%s", v, unit)
+	}
+	flips := "off"
+	if p.AllowFlips {
+		flips = "on"
+	}
+	commands := "all"
+	if len(p.Commands) > 0 {
+		commands = strings.Join(p.Commands, ",")
+	}
+	return fmt.Sprintf("%s (speed: %d, max moves: %s, max height: %s, flips: %s, commands: %s, take off battery: %d%%, auto land battery: %d%%)",
+		p.Name, p.Move, limit(p.MaxMoves, ""), limit(p.MaxHeight, "dm"), flips, commands, p.MinTakeOffBattery, p.LandBattery)
+}
@@ -0,0 +1,215 @@
+package robot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VideoBitRate is the bit rate of the video encoder of the drone
+type VideoBitRate int8
+
+const (
+	// BitRateAuto lets the drone choose the bit rate
+	BitRateAuto VideoBitRate = iota
+	// BitRate1M is 1 Mb/s
+	BitRate1M
+	// BitRate15M is 1.5 Mb/s
+	BitRate15M
+	// BitRate2M is 2 Mb/s
+	BitRate2M
+	// BitRate3M is 3 Mb/s
+	BitRate3M
+	// BitRate4M is 4 Mb/s
+	BitRate4M
+)
+
+func (r VideoBitRate) String() string {
+	switch r {
+	case BitRateAuto:
+		return "auto"
+	case BitRate1M:
+		return "1M"
+	case BitRate15M:
+		return "1.5M"
+	case BitRate2M:
+		return "2M"
+	case BitRate3M:
+		return "3M"
+	case BitRate4M:
+		return "4M"
+	default:
+		return "Unknown"
+	}
+}
+
+// ParseVideoBitRate converts the name of a bit rate (ie. auto, 1M, 1.5M) to a VideoBitRate
+func ParseVideoBitRate(name string) (VideoBitRate, error) {
+	for r := BitRateAuto; r <= BitRate4M; r++ {
+		if strings.EqualFold(r.String(), name) {
+			return r, nil
+		}
+	}
+	return BitRateAuto, fmt.Errorf("unknown bit rate %q, expected auto, 1M, 1.5M, 2M, 3M or 4M", name)
+}
+
+const (
+	// bitrateInterval is how often the video conditions are evaluated
+	bitrateInterval = 2 * time.Second
+	// videoStall is the silence between two video packets which counts as lost video
+	videoStall = 150 * time.Millisecond
+	// weakWifi is the Wi-Fi signal strength below which the bit rate is stepped down
+	weakWifi = 50
+	// strongWifi is the Wi-Fi signal strength from which the bit rate can be stepped up
+	strongWifi = 75
+	// heavyDisturb is the Wi-Fi interference from which the bit rate is stepped down
+	heavyDisturb = 50
+	// stepDownLoss is the fraction of the time the video can stall before the bit rate is stepped down
+	stepDownLoss = 0.1
+	// stepUpLoss is the fraction of the time the video can stall for the bit rate to be stepped up
+	stepUpLoss = 0.02
+	// stepUpAfter is how long the conditions need to stay good before the bit rate is stepped up
+	stepUpAfter = 10 * time.Second
+)
+
+// bitrate chooses the bit rate of the video from the Wi-Fi signal and the stalls of the video feed.
+//
+// The bit rate is stepped down as soon as the video stalls or the signal gets weak, and stepped up one step at a time
+// once the conditions have been good for a while, so the bit rate does not bounce between two steps.
+// The adaptation stops once the pilot has chosen a bit rate.
+type bitrate struct {
+	mux         sync.Mutex
+	rate        VideoBitRate
+	fixed       bool
+	strength    int8
+	disturb     int8
+	lastPacket  time.Time
+	windowStart time.Time
+	stalled     time.Duration
+	goodSince   time.Time
+}
+
+func newBitrate() *bitrate {
+	return &bitrate{rate: BitRate2M}
+}
+
+// packet records the arrival of a video packet
+func (b *bitrate) packet(now time.Time) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.stall(now)
+	b.lastPacket = now
+	if b.windowStart.IsZero() {
+		b.windowStart = now
+	}
+}
+
+// stall adds the silence since the last packet to the stalled time of the window. It must be called with the lock held.
+func (b *bitrate) stall(now time.Time) {
+	if b.lastPacket.IsZero() || now.Sub(b.lastPacket) <= videoStall {
+		return
+	}
+	// The part of the silence before the window started has been counted in the previous window
+	from := b.lastPacket
+	if b.windowStart.After(from) {
+		from = b.windowStart
+	}
+	b.stalled += now.Sub(from)
+}
+
+// wifi records the Wi-Fi conditions reported by the drone
+func (b *bitrate) wifi(strength, disturb int8) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.strength, b.disturb = strength, disturb
+}
+
+// set fixes the bit rate and stops the adaptation
+func (b *bitrate) set(rate VideoBitRate) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.rate, b.fixed = rate, true
+}
+
+// step moves the bit rate up or down and stops the adaptation. The drone's own choice is replaced with the middle step.
+// It returns false if the bit rate is already at the limit.
+func (b *bitrate) step(delta VideoBitRate) (VideoBitRate, bool) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.fixed = true
+	rate := b.rate
+	if rate == BitRateAuto {
+		rate = BitRate2M
+	}
+	rate += delta
+	if rate < BitRate1M || rate > BitRate4M {
+		return b.rate, false
+	}
+	b.rate = rate
+	return b.rate, true
+}
+
+// adapt resumes the adaptation from the current bit rate. The drone's own choice is replaced with the middle step.
+func (b *bitrate) adapt() {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.fixed = false
+	b.goodSince = time.Time{}
+	if b.rate == BitRateAuto {
+		b.rate = BitRate2M
+	}
+}
+
+// streaming returns true once the video packets have started to arrive
+func (b *bitrate) streaming() bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return !b.windowStart.IsZero()
+}
+
+func (b *bitrate) current() VideoBitRate {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.rate
+}
+
+// evaluate closes the current window and returns the bit rate for the next one along with the fraction of the window the video stalled
+func (b *bitrate) evaluate(now time.Time) (rate VideoBitRate, changed bool, loss float64) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.windowStart.IsZero() {
+		// The video has not started yet
+		return b.rate, false, 0
+	}
+	b.stall(now)
+	if window := now.Sub(b.windowStart); window > 0 {
+		loss = float64(b.stalled) / float64(window)
+	}
+	b.windowStart, b.stalled = now, 0
+	if b.fixed {
+		return b.rate, false, loss
+	}
+
+	bad := loss > stepDownLoss || (b.strength > 0 && b.strength < weakWifi) || b.disturb >= heavyDisturb
+	good := loss < stepUpLoss && b.strength >= strongWifi && b.disturb < heavyDisturb
+	switch {
+	case bad:
+		b.goodSince = time.Time{}
+		if b.rate > BitRate1M {
+			b.rate--
+			return b.rate, true, loss
+		}
+	case good:
+		if b.goodSince.IsZero() {
+			b.goodSince = now
+		} else if now.Sub(b.goodSince) >= stepUpAfter && b.rate < BitRate4M {
+			b.rate++
+			b.goodSince = now
+			return b.rate, true, loss
+		}
+	default:
+		b.goodSince = time.Time{}
+	}
+	return b.rate, false, loss
+}
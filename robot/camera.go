@@ -0,0 +1,43 @@
+package robot
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"gobot.io/x/gobot/platforms/dji/tello"
+)
+
+// Recorder records the video feed on the host, since the drone cannot record by itself
+type Recorder interface {
+	// Toggle pauses or resumes the recording. It returns true if the video is being recorded.
+	Toggle() bool
+}
+
+const (
+	// packetStart is the first byte of every packet of the binary protocol
+	packetStart = 0xcc
+	// setPacket is the type of the packets which change a setting of the drone
+	setPacket = 0x68
+	// videoModeCommand switches the picture between the normal and the wide video
+	videoModeCommand = 0x0031
+)
+
+// videoModePacket builds the request to switch between the normal (4:3) and the wide (16:9) video.
+// The gobot driver does not support it, so the packet is built the same way the driver builds the others.
+func videoModePacket(seq int16, wide bool) []byte {
+	var mode byte
+	if wide {
+		mode = 1
+	}
+	buf := &bytes.Buffer{}
+	// The size of the packet includes the header, the sequence, the payload and the checksums
+	_ = binary.Write(buf, binary.LittleEndian, byte(packetStart))
+	_ = binary.Write(buf, binary.LittleEndian, int16(12)<<3)
+	_ = binary.Write(buf, binary.LittleEndian, tello.CalculateCRC8(buf.Bytes()[0:3]))
+	_ = binary.Write(buf, binary.LittleEndian, byte(setPacket))
+	_ = binary.Write(buf, binary.LittleEndian, int16(videoModeCommand))
+	_ = binary.Write(buf, binary.LittleEndian, seq)
+	_ = binary.Write(buf, binary.LittleEndian, mode)
+	_ = binary.Write(buf, binary.LittleEndian, tello.CalculateCRC16(buf.Bytes()))
+	return buf.Bytes()
+}
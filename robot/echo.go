@@ -24,8 +24,14 @@ func (e *Echo) Errors() <-chan error {
 // Connect is a blocking call which blocks until the context has been cancelled
 func (e *Echo) Connect(source input.Source) error {
 	defer close(e.errors)
+	ack, _ := source.(input.Acknowledger)
+	var id uint64
 	for cmd := range source.Commands() {
+		id++
 		fmt.Printf("Command Received: %s\n", cmd)
+		if ack != nil {
+			ack.Acknowledge(input.Result{ID: id, Command: cmd, Outcome: input.Executed})
+		}
 	}
 	return nil
 }
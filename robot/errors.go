@@ -0,0 +1,83 @@
+package robot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xitonix/gophobotics/input"
+)
+
+var (
+	// ErrNotAirborne is returned when a command which needs the drone to be flying is issued on the ground
+	ErrNotAirborne = errors.New("the drone is not airborne")
+	// ErrOverLimit is returned when the maximum number of moves in a direction has been reached
+	ErrOverLimit = errors.New("the maximum number of moves has been reached")
+	// ErrBatteryLow is returned when the battery is too low to execute the command safely
+	ErrBatteryLow = errors.New("the battery is too low")
+	// ErrUnsupported is returned when the robot does not know how to execute a command
+	ErrUnsupported = errors.New("the command is not supported by the robot")
+	// ErrTooHigh is returned when the drone is too high to land on a palm
+	ErrTooHigh = errors.New("the drone is too high to land on a palm")
+	// ErrHeightUnknown is returned when the drone has not reported its height, so it may be too high to land on a palm
+	ErrHeightUnknown = errors.New("the height of the drone is unknown")
+	// ErrAirborne is returned when a command which needs the drone to be on the ground (ie. a throw) is issued in the air
+	ErrAirborne = errors.New("the drone is already airborne")
+	// ErrDisconnected is returned when the connection to the drone has been lost
+	ErrDisconnected = errors.New("the connection to the drone has been lost")
+)
+
+// Phase is the stage of the command execution in which an error has occurred
+type Phase int8
+
+const (
+	// PhaseSend means the command could not be sent to the drone
+	PhaseSend Phase = iota
+	// PhaseValidate means the command is not valid in the current state of the drone
+	PhaseValidate
+	// PhaseLimit means the command has been rejected by the safety limits
+	PhaseLimit
+	// PhaseLink means the link to the drone is not healthy
+	PhaseLink
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseSend:
+		return "Send"
+	case PhaseValidate:
+		return "Validate"
+	case PhaseLimit:
+		return "Limit"
+	case PhaseLink:
+		return "Link"
+	default:
+		return "Unknown"
+	}
+}
+
+// CommandError is the error the robots report when something goes wrong.
+// Use errors.Is with the sentinel errors of this package to find out what went wrong.
+type CommandError struct {
+	// Command is the command which failed. It is input.None if the error is not caused by a command
+	Command input.Command
+	// Phase is the stage in which the command failed
+	Phase Phase
+	// Retryable is true if issuing the same command again later may succeed
+	Retryable bool
+	// State is the drone state at the time of the failure
+	State DroneState
+	// Err is the underlying error
+	Err error
+}
+
+func (e *CommandError) Error() string {
+	if e.Command == input.None {
+		return fmt.Sprintf("%s failure: %s", e.Phase, e.Err)
+	}
+	return fmt.Sprintf("%s command failed (%s): %s", e.Command, e.Phase, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,153 @@
+package robot
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Exposure is the exposure level of the drone camera. The higher the level, the brighter the picture.
+type Exposure int8
+
+const (
+	// MinExposure is the darkest exposure level, which the drone starts with
+	MinExposure Exposure = 0
+	// MaxExposure is the brightest exposure level
+	MaxExposure Exposure = 2
+)
+
+func (e Exposure) String() string {
+	return strconv.Itoa(int(e))
+}
+
+// ParseExposure converts the name of an exposure level (ie. 0, 1 or 2) to an Exposure
+func ParseExposure(name string) (Exposure, error) {
+	level, err := strconv.Atoi(name)
+	if err != nil || Exposure(level) < MinExposure || Exposure(level) > MaxExposure {
+		return MinExposure, fmt.Errorf("unknown exposure %q, expected %s to %s", name, MinExposure, MaxExposure)
+	}
+	return Exposure(level), nil
+}
+
+const (
+	// exposureInterval is how often the light is evaluated
+	exposureInterval = time.Second
+	// darkFrame is the mean brightness of the picture (0-255) below which the exposure is stepped up
+	darkFrame = 70
+	// brightFrame is the mean brightness of the picture (0-255) above which the exposure is stepped down
+	brightFrame = 180
+	// exposureHold is how long the picture needs to stay too dark or too bright before the exposure is changed
+	exposureHold = 3 * time.Second
+	// brightnessAge is how long the brightness of a frame is trusted. The light strength is used once the frames have stopped.
+	brightnessAge = 2 * time.Second
+)
+
+// exposure chooses the exposure level of the camera from the light strength reported by the drone,
+// or the brightness of the picture when the video is being decoded.
+//
+// The gap between darkFrame and brightFrame is wider than the effect of a single step, and the conditions need to last for exposureHold,
+// so the level does not bounce between two steps. The adaptation stops once the pilot has chosen a level.
+type exposure struct {
+	mux        sync.Mutex
+	level      Exposure
+	fixed      bool
+	lowLight   bool
+	brightness float64
+	measured   time.Time
+	darkSince  time.Time
+	lightSince time.Time
+}
+
+func newExposure() *exposure {
+	return &exposure{level: MinExposure}
+}
+
+// light records the light strength reported by the drone. A non zero strength means the camera is short of light.
+func (e *exposure) light(strength int8) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.lowLight = strength != 0
+}
+
+// frame records the mean brightness of a decoded frame
+func (e *exposure) frame(now time.Time, brightness float64) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.brightness, e.measured = brightness, now
+}
+
+// set fixes the exposure level and stops the adaptation
+func (e *exposure) set(level Exposure) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.level, e.fixed = level, true
+}
+
+// step moves the exposure level up or down and stops the adaptation. It returns false if the level is already at the limit.
+func (e *exposure) step(delta Exposure) (Exposure, bool) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.fixed = true
+	level := e.level + delta
+	if level < MinExposure || level > MaxExposure {
+		return e.level, false
+	}
+	e.level = level
+	return e.level, true
+}
+
+// adapt resumes the adaptation from the current level
+func (e *exposure) adapt() {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.fixed = false
+	e.darkSince, e.lightSince = time.Time{}, time.Time{}
+}
+
+// current returns the exposure level and whether it's being adapted to the light
+func (e *exposure) current() (Exposure, bool) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	return e.level, !e.fixed
+}
+
+// evaluate returns the exposure level for the current light
+func (e *exposure) evaluate(now time.Time) (Exposure, bool) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	if e.fixed {
+		return e.level, false
+	}
+
+	var dark, bright bool
+	if !e.measured.IsZero() && now.Sub(e.measured) <= brightnessAge {
+		dark, bright = e.brightness < darkFrame, e.brightness > brightFrame
+	} else {
+		dark, bright = e.lowLight, !e.lowLight
+	}
+
+	switch {
+	case dark:
+		e.lightSince = time.Time{}
+		if e.darkSince.IsZero() {
+			e.darkSince = now
+		} else if now.Sub(e.darkSince) >= exposureHold && e.level < MaxExposure {
+			e.level++
+			e.darkSince = now
+			return e.level, true
+		}
+	case bright:
+		e.darkSince = time.Time{}
+		if e.lightSince.IsZero() {
+			e.lightSince = now
+		} else if now.Sub(e.lightSince) >= exposureHold && e.level > MinExposure {
+			e.level--
+			e.lightSince = now
+			return e.level, true
+		}
+	default:
+		e.darkSince, e.lightSince = time.Time{}, time.Time{}
+	}
+	return e.level, false
+}
@@ -0,0 +1,15 @@
+package robot
+
+// MaxPalmLandHeight is the height in decimetres above which the drone is not allowed to land on a palm.
+// The drone drops onto whatever is under it, so it must be low enough for the pilot to be holding a palm under it.
+const MaxPalmLandHeight = 15
+
+// Limits are the safety limits the robots enforce on top of the maximum number of moves
+type Limits struct {
+	// MaxHeight is the maximum height in decimetres the drone is allowed to climb to. Zero means no limit.
+	MaxHeight int16
+	// MinTakeOffBattery is the minimum battery percentage required to take off
+	MinTakeOffBattery int8
+	// LandBattery is the battery percentage below which the drone lands automatically
+	LandBattery int8
+}
@@ -0,0 +1,150 @@
+package robot
+
+import (
+	"sync"
+	"time"
+)
+
+// ConnectionState represents the health of the link between the host and the drone
+type ConnectionState int8
+
+const (
+	// Connecting is the initial state until the first heartbeat is received from the drone
+	Connecting ConnectionState = iota
+	// Connected means the drone heartbeat is arriving on time
+	Connected
+	// Degraded means the drone heartbeat is late, but the link has not been given up yet
+	Degraded
+	// Lost means no heartbeat has been received for a while and the robot is trying to reconnect
+	Lost
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case Connecting:
+		return "Connecting"
+	case Connected:
+		return "Connected"
+	case Degraded:
+		return "Degraded"
+	case Lost:
+		return "Lost"
+	default:
+		return "Unknown"
+	}
+}
+
+// Failsafe is the action the drone is asked to take when the link is lost while it is airborne
+type Failsafe int8
+
+const (
+	// FailsafeHover stops all the movements and keeps the drone in place
+	FailsafeHover Failsafe = iota
+	// FailsafeLand lands the drone
+	FailsafeLand
+)
+
+func (f Failsafe) String() string {
+	switch f {
+	case FailsafeHover:
+		return "Hover"
+	case FailsafeLand:
+		return "Land"
+	default:
+		return "Unknown"
+	}
+}
+
+// ParseFailsafe converts the name of a failsafe action to a Failsafe value. It falls back to FailsafeHover.
+func ParseFailsafe(name string) Failsafe {
+	if name == "land" || name == "Land" {
+		return FailsafeLand
+	}
+	return FailsafeHover
+}
+
+// LinkPolicy defines how the link with the drone is supervised
+type LinkPolicy struct {
+	// DegradedAfter is the heartbeat silence after which the link is reported as degraded
+	DegradedAfter time.Duration
+	// LostAfter is the heartbeat silence after which the link is reported as lost
+	LostAfter time.Duration
+	// MinBackoff is the delay before the first reconnection attempt
+	MinBackoff time.Duration
+	// MaxBackoff is the upper bound of the delay between two reconnection attempts
+	MaxBackoff time.Duration
+	// Failsafe is the action to take when the link is lost while the drone is airborne
+	Failsafe Failsafe
+}
+
+// DefaultLinkPolicy returns the link policy the robots use unless told otherwise
+func DefaultLinkPolicy() LinkPolicy {
+	return LinkPolicy{
+		DegradedAfter: time.Second,
+		LostAfter:     3 * time.Second,
+		MinBackoff:    500 * time.Millisecond,
+		MaxBackoff:    8 * time.Second,
+		Failsafe:      FailsafeHover,
+	}
+}
+
+// linkCheckInterval is how often the heartbeat is evaluated
+const linkCheckInterval = 200 * time.Millisecond
+
+// link keeps track of the drone heartbeat and derives the connection state from it
+type link struct {
+	mux      sync.Mutex
+	policy   LinkPolicy
+	state    ConnectionState
+	lastBeat time.Time
+	heard    bool
+}
+
+func newLink(policy LinkPolicy) *link {
+	return &link{
+		policy: policy,
+		state:  Connecting,
+	}
+}
+
+// start resets the heartbeat clock so that a drone which never responds is eventually reported as lost
+func (l *link) start(now time.Time) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.state = Connecting
+	l.lastBeat = now
+	l.heard = false
+}
+
+// beat records a heartbeat from the drone
+func (l *link) beat(now time.Time) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.lastBeat = now
+	l.heard = true
+}
+
+func (l *link) current() ConnectionState {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.state
+}
+
+// evaluate works out the connection state at the given time and returns the previous and the new states
+func (l *link) evaluate(now time.Time) (ConnectionState, ConnectionState) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	previous := l.state
+	silence := now.Sub(l.lastBeat)
+	switch {
+	case silence >= l.policy.LostAfter:
+		l.state = Lost
+	case !l.heard:
+		// Still waiting for the very first heartbeat
+	case silence >= l.policy.DegradedAfter:
+		l.state = Degraded
+	default:
+		l.state = Connected
+	}
+	return previous, l.state
+}
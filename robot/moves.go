@@ -0,0 +1,48 @@
+package robot
+
+import "github.com/xitonix/gophobotics/input"
+
+// moves keeps track of the number of moves in each direction. A move in one direction cancels a move in the opposite direction.
+type moves struct {
+	forward int
+	back    int
+	left    int
+	right   int
+	up      int
+	down    int
+}
+
+// register records the movement command and returns true if the maximum number of moves in its direction has been reached
+func (m *moves) register(cmd input.Command, max int) bool {
+	if max <= 0 {
+		return false
+	}
+	var current int
+	switch cmd {
+	case input.Left:
+		current = m.step(&m.left, &m.right, max)
+	case input.Right:
+		current = m.step(&m.right, &m.left, max)
+	case input.Forward:
+		current = m.step(&m.forward, &m.back, max)
+	case input.Backward:
+		current = m.step(&m.back, &m.forward, max)
+	case input.Up:
+		current = m.step(&m.up, &m.down, max)
+	case input.Down:
+		current = m.step(&m.down, &m.up, max)
+	default:
+		return false
+	}
+	return current >= max
+}
+
+func (m *moves) step(towards, opposite *int, max int) int {
+	if *towards < max {
+		*towards++
+	}
+	if *opposite > 0 {
+		*opposite--
+	}
+	return *towards
+}
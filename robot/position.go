@@ -0,0 +1,95 @@
+package robot
+
+import (
+	"errors"
+	"math"
+
+	"github.com/xitonix/gophobotics/input"
+)
+
+var (
+	// ErrPositionUnknown is returned when the geofence cannot work out where the drone is
+	ErrPositionUnknown = errors.New("the position of the drone is unknown")
+	// ErrOutsideFence is returned when a move would take the drone outside of the geofence
+	ErrOutsideFence = errors.New("the move would take the drone outside of the fence")
+)
+
+// Position is an absolute position in centimetres. X points to the front of the mission pads, Y to the left and Z up.
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// PositionSource provides the absolute position of the drone
+type PositionSource interface {
+	// Position returns the position of the drone and its heading in degrees measured clockwise from X.
+	// It returns false if the position is not known at the moment.
+	Position() (Position, float64, bool)
+}
+
+// PadLayout is where each mission pad has been placed by pad ID. All the pads must face the same direction.
+type PadLayout map[int]Position
+
+// padPositions works out the position of the drone from the mission pad it is flying over
+type padPositions struct {
+	layout PadLayout
+	state  func() DroneState
+}
+
+// PadPositions returns a position source which locates the drone using the mission pads laid out on the floor
+func PadPositions(layout PadLayout, state func() DroneState) PositionSource {
+	return &padPositions{layout: layout, state: state}
+}
+
+func (p *padPositions) Position() (Position, float64, bool) {
+	pad := p.state().Pad
+	if !pad.Detected() {
+		return Position{}, 0, false
+	}
+	origin, ok := p.layout[pad.ID]
+	if !ok {
+		return Position{}, 0, false
+	}
+	return Position{
+		X: origin.X + float64(pad.X),
+		Y: origin.Y + float64(pad.Y),
+		Z: origin.Z + float64(pad.Z),
+	}, float64(pad.Yaw), true
+}
+
+// Fence is the box the drone is allowed to fly in
+type Fence struct {
+	Min Position `json:"min"`
+	Max Position `json:"max"`
+}
+
+// Contains returns true if the position is inside the fence
+func (f Fence) Contains(p Position) bool {
+	return p.X >= f.Min.X && p.X <= f.Max.X &&
+		p.Y >= f.Min.Y && p.Y <= f.Max.Y &&
+		p.Z >= f.Min.Z && p.Z <= f.Max.Z
+}
+
+// moveTo estimates where a movement command takes the drone
+func moveTo(p Position, heading float64, cmd input.Command, distance float64) Position {
+	rad := heading * math.Pi / 180
+	// Turning clockwise moves the front of the drone from X towards -Y
+	forwardX, forwardY := math.Cos(rad), -math.Sin(rad)
+	rightX, rightY := -math.Sin(rad), -math.Cos(rad)
+	switch cmd {
+	case input.Forward:
+		p.X, p.Y = p.X+forwardX*distance, p.Y+forwardY*distance
+	case input.Backward:
+		p.X, p.Y = p.X-forwardX*distance, p.Y-forwardY*distance
+	case input.Right:
+		p.X, p.Y = p.X+rightX*distance, p.Y+rightY*distance
+	case input.Left:
+		p.X, p.Y = p.X-rightX*distance, p.Y-rightY*distance
+	case input.Up:
+		p.Z += distance
+	case input.Down:
+		p.Z -= distance
+	}
+	return p
+}
@@ -0,0 +1,155 @@
+package robot
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/logging"
+)
+
+// Options are the settings the robots are created with. Each robot ignores the settings it does not support.
+type Options struct {
+	// Move is the speed of the robot (0-100)
+	Move int
+	// MaxMoves is the maximum number of moves in each direction. Zero means no limit.
+	MaxMoves int
+	// Address is where the drone can be reached
+	Address TelloAddress
+	// Limits overrides the default safety limits if it's not nil
+	Limits *Limits
+	// Policy overrides the default link supervision policy if it's not nil
+	Policy *LinkPolicy
+	// Swarm is the drones of the swarm
+	Swarm []SwarmMember
+	// Targets are the swarm members or groups the commands are sent to. The commands are broadcast if it's empty.
+	Targets []string
+	// MissionPads turns on the mission pad detection of the Tello EDU drones
+	MissionPads bool
+	// Pads is where the mission pads have been placed
+	Pads PadLayout
+	// Fence keeps the drone inside a box if it's not nil. The drone is located using the mission pads.
+	Fence *Fence
+	// VideoBitRate fixes the bit rate of the video if it's not nil. The bit rate is adapted to the Wi-Fi conditions otherwise.
+	VideoBitRate *VideoBitRate
+	// Exposure fixes the exposure level of the camera if it's not nil. The exposure is adapted to the light otherwise.
+	Exposure *Exposure
+	// Logger is the logger of the robot
+	Logger *logging.Logger
+	// Bus is the event bus the robot publishes its events to
+	Bus *event.Bus
+}
+
+// Factory creates a new robot
+type Factory func(options Options) (Robot, error)
+
+// Registration describes a registered robot
+type Registration struct {
+	Name        string
+	Description string
+	factory     Factory
+}
+
+var (
+	registryMux sync.Mutex
+	registry    = make(map[string]Registration)
+)
+
+func init() {
+	Register("echo", "Prints the commands to standard output", func(Options) (Robot, error) {
+		return NewEcho(), nil
+	})
+	Register("tello", "DJI Ryze Tello drone", func(options Options) (Robot, error) {
+		t, err := NewTelloAt(options.Address, options.Move, options.MaxMoves, options.Logger)
+		if err != nil {
+			return nil, err
+		}
+		if options.Limits != nil {
+			t.SetLimits(*options.Limits)
+		}
+		if options.Policy != nil {
+			t.SuperviseLink(*options.Policy)
+		}
+		if options.VideoBitRate != nil {
+			t.SetVideoBitRate(*options.VideoBitRate)
+		}
+		if options.Exposure != nil {
+			t.SetExposure(*options.Exposure)
+		}
+		t.SetEventBus(options.Bus)
+		return t, nil
+	})
+	Register("tello-sdk", "Tello or Tello EDU drone using the official SDK text protocol", func(options Options) (Robot, error) {
+		s := NewSDK(options.Address, options.Move, options.MaxMoves, options.Logger)
+		if options.Limits != nil {
+			s.SetLimits(*options.Limits)
+		}
+		s.SetMissionPads(options.MissionPads)
+		s.SetPadLayout(options.Pads)
+		if options.Fence != nil {
+			if !options.MissionPads {
+				return nil, fmt.Errorf("the fence needs the mission pads to locate the drone")
+			}
+			s.SetFence(*options.Fence, nil)
+		}
+		if options.Policy != nil {
+			s.SuperviseLink(*options.Policy)
+		}
+		s.SetEventBus(options.Bus)
+		return s, nil
+	})
+	Register("swarm", "Several Tello drones in station mode", func(options Options) (Robot, error) {
+		s, err := NewSwarm(options.Swarm, options.Move, options.MaxMoves, options.Logger)
+		if err != nil {
+			return nil, err
+		}
+		if options.Limits != nil {
+			s.SetLimits(*options.Limits)
+		}
+		// The swarm does not supervise the link, which config.Validate makes sure the failsafe does not rely on
+		if err := s.Target(options.Targets...); err != nil {
+			return nil, err
+		}
+		s.SetEventBus(options.Bus)
+		return s, nil
+	})
+}
+
+// Register makes a robot available by name. Registering a robot under an existing name replaces it.
+func Register(name, description string, factory Factory) {
+	registryMux.Lock()
+	defer registryMux.Unlock()
+	registry[name] = Registration{Name: name, Description: description, factory: factory}
+}
+
+// IsRegistered returns true if a robot has been registered under the name
+func IsRegistered(name string) bool {
+	registryMux.Lock()
+	defer registryMux.Unlock()
+	_, ok := registry[name]
+	return ok
+}
+
+// Registered returns all the registered robots sorted by name
+func Registered() []Registration {
+	registryMux.Lock()
+	defer registryMux.Unlock()
+	list := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// New creates the robot registered under the name
+func New(name string, options Options) (Robot, error) {
+	registryMux.Lock()
+	r, ok := registry[name]
+	registryMux.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown robot %q", name)
+	}
+	return r.factory(options)
+}
@@ -0,0 +1,26 @@
+package robot
+
+import "github.com/xitonix/gophobotics/input"
+
+// Robot is an interface for the robots which are controlled by the commands of an input source
+type Robot interface {
+	// Connect establishes a new connection to the robot and blocks until the source's Commands channel is closed.
+	// The source is read to the end even if the robot cannot be reached, so that it's never left blocked.
+	Connect(source input.Source) error
+	// Errors returns any errors occurred during the execution of a command
+	Errors() <-chan error
+}
+
+// drain reads the commands of the source until its Commands channel is closed and reports each of them as failed.
+// It's used once a robot has failed to connect, so that the source (ie. the keyboard) can still be closed using Exit.
+func drain(source input.Source, reason error) {
+	ack, _ := source.(input.Acknowledger)
+	var id uint64
+	for cmd := range source.Commands() {
+		id++
+		if cmd == input.None || ack == nil {
+			continue
+		}
+		ack.Acknowledge(input.Result{ID: id, Command: cmd, Outcome: input.Failed, Reason: reason})
+	}
+}
@@ -0,0 +1,592 @@
+package robot
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
+	"github.com/xitonix/gophobotics/sdk"
+)
+
+// sdkPublisher is the name the SDK robot publishes the events under
+const sdkPublisher = "tello-sdk"
+
+const (
+	// sdkTimeout is how long the robot waits for the drone to finish a move and respond
+	sdkTimeout = 10 * time.Second
+	// sdkFlightTimeout is how long the robot waits for the drone to take off or land
+	sdkFlightTimeout = 20 * time.Second
+	// sdkKeepAlive is how often the drone is pinged while idle. The drone lands if it does not hear from the host for 15 seconds.
+	sdkKeepAlive = 5 * time.Second
+	// sdkLowBattery is the battery percentage below which the battery is considered low
+	sdkLowBattery = 10
+)
+
+// SDK implements the Robot interface and flies a Tello or Tello EDU drone using the official SDK text protocol.
+//
+// Unlike the Tello robot, every command is acknowledged by the drone once it has been carried out, so the commands are
+// executed one at a time and a command which is not acknowledged in time fails. Each move flies the drone a fixed distance
+// in centimetres (20-500) and each rotation turns it a fixed angle in degrees (1-360), both set by the move value.
+type SDK struct {
+	address          TelloAddress
+	distance, angle  int
+	maxNumberOfMoves int
+	moves            moves
+	errors           chan error
+	logger           *logging.Logger
+	bus              *event.Bus
+	limits           Limits
+	link             *link
+	telemetry        telemetry
+	acknowledger     input.Acknowledger
+	missionPads      bool
+	layout           PadLayout
+	fence            *Fence
+	positions        PositionSource
+	// timeout and flightTimeout are how long the drone is given to carry out a move, and to take off or land
+	timeout, flightTimeout time.Duration
+
+	clientMux sync.Mutex
+	client    *sdk.Client
+	stop      chan interface{}
+	wg        sync.WaitGroup
+	// inFlight is the number of commands waiting for the drone to carry them out
+	inFlight int32
+}
+
+// NewSDK creates a new robot which talks to the drone using the SDK text protocol
+func NewSDK(address TelloAddress, move, maxNumberOfMoves int, logger *logging.Logger) *SDK {
+	return &SDK{
+		address:          address,
+		distance:         clamp(move, 20, 500),
+		angle:            clamp(move, 1, 360),
+		maxNumberOfMoves: maxNumberOfMoves,
+		errors:           make(chan error),
+		logger:           logger.With(logging.Fields{"robot": sdkPublisher}),
+		link:             newLink(DefaultLinkPolicy()),
+		timeout:          sdkTimeout,
+		flightTimeout:    sdkFlightTimeout,
+	}
+}
+
+// Errors returns any errors occurred during the execution of a command.
+// MAKE SURE you always read from this channel before calling the Connect method to avoid deadlock
+func (s *SDK) Errors() <-chan error {
+	return s.errors
+}
+
+// SuperviseLink overrides the default link supervision policy.
+// It must be called before Connect or Dial.
+func (s *SDK) SuperviseLink(policy LinkPolicy) {
+	s.link = newLink(policy)
+}
+
+// SetLimits sets the safety limits the robot enforces on top of the maximum number of moves.
+// It must be called before Connect or Dial.
+func (s *SDK) SetLimits(limits Limits) {
+	s.limits = limits
+}
+
+// SetEventBus sets the bus the robot publishes its lifecycle and flight events to.
+// It must be called before Connect or Dial.
+func (s *SDK) SetEventBus(bus *event.Bus) {
+	s.bus = bus
+}
+
+// SetMissionPads turns the mission pad detection of the Tello EDU drones on or off.
+// It must be called before Connect or Dial.
+func (s *SDK) SetMissionPads(enabled bool) {
+	s.missionPads = enabled
+}
+
+// SetPadLayout sets where the mission pads have been placed, so the drone can be located while flying over them.
+// It must be called before Connect or Dial.
+func (s *SDK) SetPadLayout(layout PadLayout) {
+	s.layout = layout
+}
+
+// SetFence keeps the drone inside the fence. The moves are rejected if they would take the drone outside of the fence,
+// or if the position of the drone cannot be worked out. The drone is located using the mission pads if positions is nil.
+// It must be called before Connect or Dial.
+func (s *SDK) SetFence(fence Fence, positions PositionSource) {
+	s.fence = &fence
+	if positions == nil {
+		positions = s
+	}
+	s.positions = positions
+}
+
+// Position returns the position of the drone based on the mission pad it is flying over
+func (s *SDK) Position() (Position, float64, bool) {
+	return PadPositions(s.layout, s.State).Position()
+}
+
+// RotationAngle returns the angle in degrees the drone turns with each rotation command
+func (s *SDK) RotationAngle() int {
+	return s.angle
+}
+
+// State returns a snapshot of the drone state
+func (s *SDK) State() DroneState {
+	state := s.telemetry.snapshot()
+	state.Connection = s.link.current()
+	return state
+}
+
+// Connect enters the SDK mode of the drone and blocks until the source's Commands channel is closed.
+func (s *SDK) Connect(source input.Source) error {
+	defer close(s.errors)
+	if err := s.Dial(); err != nil {
+		s.logger.Errorf("Drone: %s", err)
+		drain(source, ErrDisconnected)
+		return err
+	}
+
+	s.acknowledger, _ = source.(input.Acknowledger)
+	var id uint64
+	for cmd := range source.Commands() {
+		id++
+		if cmd == input.None {
+			continue
+		}
+		if cmd == input.Exit {
+			s.printCommand(cmd)
+			s.acknowledge(id, cmd, input.Executed, nil)
+			break
+		}
+		if err := s.Execute(cmd); err != nil {
+			s.errors <- err
+			if err.Phase == PhaseSend {
+				s.acknowledge(id, cmd, input.Failed, err)
+			} else {
+				s.acknowledge(id, cmd, input.Rejected, err)
+			}
+			continue
+		}
+		s.printCommand(cmd)
+		s.acknowledge(id, cmd, input.Executed, nil)
+	}
+
+	if err := s.Close(); err != nil {
+		s.errors <- err
+	}
+	return nil
+}
+
+// Dial enters the SDK mode of the drone without reading any commands, so that the drone can be driven using
+// Execute, FlyToPad and Jump. Close must be called once the drone is no longer needed.
+func (s *SDK) Dial() error {
+	client, err := sdk.Open(sdk.Address{
+		IP:          s.address.IP,
+		CommandPort: s.address.CommandPort,
+		LocalPort:   s.address.LocalPort,
+		StatePort:   s.address.StatePort,
+	})
+	if err != nil {
+		return err
+	}
+	client.OnState(s.stateReceived)
+	s.link.start(time.Now())
+	if err := client.Do("command", 0); err != nil {
+		_ = client.Close()
+		return fmt.Errorf("failed to enter the SDK mode: %s", err)
+	}
+	if s.missionPads {
+		// Direction 0 only looks down, which detects the pads at the highest rate
+		for _, command := range []string{"mon", "mdirection 0"} {
+			if err := client.Do(command, 0); err != nil {
+				_ = client.Close()
+				return fmt.Errorf("failed to turn on the mission pad detection: %s", err)
+			}
+		}
+	}
+	s.link.beat(time.Now())
+	s.clientMux.Lock()
+	s.client = client
+	s.clientMux.Unlock()
+	s.logger.Infof("Drone: Connected to %s:%d", s.address.IP, s.address.CommandPort)
+
+	s.stop = make(chan interface{})
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.supervise(s.stop)
+	}()
+	return nil
+}
+
+// Close lands the drone if it is flying and closes the connection. It only needs to be called after Dial.
+func (s *SDK) Close() error {
+	s.clientMux.Lock()
+	client := s.client
+	s.client = nil
+	s.clientMux.Unlock()
+	if client == nil {
+		return nil
+	}
+	close(s.stop)
+	s.wg.Wait()
+	var err error
+	if s.telemetry.snapshot().Airborne {
+		if landErr := client.Do("land", s.flightTimeout); landErr != nil {
+			err = s.commandError(input.Land, PhaseSend, false, landErr)
+		}
+	}
+	s.bus.Publish(event.New(event.Disconnected, sdkPublisher, s.State()))
+	_ = client.Close()
+	return err
+}
+
+// Execute validates the command and blocks until the drone has carried it out
+func (s *SDK) Execute(cmd input.Command) *CommandError {
+	if err := s.validate(cmd); err != nil {
+		return err
+	}
+	return s.execute(cmd)
+}
+
+// FlyToPad flies the drone to x, y and z centimetres from the mission pad at the specified speed (10-100 cm/s).
+// X points to the front of the pad, Y to the left and Z up. The pad must be visible to the drone.
+func (s *SDK) FlyToPad(pad, x, y, z, speed int) *CommandError {
+	if err := s.validatePad(pad, x, y, z, speed); err != nil {
+		return err
+	}
+	return s.send(input.None, fmt.Sprintf("go %d %d %d %d m%d", x, y, z, speed, pad), s.timeout)
+}
+
+// Jump flies the drone to x, y and z centimetres from the mission pad it is flying over,
+// then finds the next pad and hovers z centimetres above it, facing yaw degrees relative to the pad.
+func (s *SDK) Jump(from, to, x, y, z, speed, yaw int) *CommandError {
+	if err := s.validatePad(from, x, y, z, speed); err != nil {
+		return err
+	}
+	if to < 1 || to > 8 {
+		return s.commandError(input.None, PhaseValidate, false, fmt.Errorf("invalid mission pad m%d", to))
+	}
+	return s.send(input.None, fmt.Sprintf("jump %d %d %d %d %d m%d m%d", x, y, z, speed, yaw, from, to), s.timeout)
+}
+
+// execute sends the command to the drone and waits for the acknowledgement
+func (s *SDK) execute(cmd input.Command) *CommandError {
+	var text string
+	timeout := s.timeout
+	switch cmd {
+	case input.TakeOff:
+		text, timeout = "takeoff", s.flightTimeout
+	case input.Land:
+		text, timeout = "land", s.flightTimeout
+	case input.Forward:
+		text = fmt.Sprintf("forward %d", s.distance)
+	case input.Backward:
+		text = fmt.Sprintf("back %d", s.distance)
+	case input.Left:
+		text = fmt.Sprintf("left %d", s.distance)
+	case input.Right:
+		text = fmt.Sprintf("right %d", s.distance)
+	case input.Up:
+		text = fmt.Sprintf("up %d", s.distance)
+	case input.Down:
+		text = fmt.Sprintf("down %d", s.distance)
+	case input.RotateRight:
+		text = fmt.Sprintf("cw %d", s.angle)
+	case input.RotateLeft:
+		text = fmt.Sprintf("ccw %d", s.angle)
+	case input.FrontFlip:
+		text = "flip f"
+	case input.BackFlip:
+		text = "flip b"
+	case input.LeftFlip:
+		text = "flip l"
+	case input.RightFlip:
+		text = "flip r"
+	default:
+		return s.commandError(cmd, PhaseValidate, false, ErrUnsupported)
+	}
+	// The moves which the drone does not carry out must not count towards the limit
+	previous := s.moves
+	if s.moves.register(cmd, s.maxNumberOfMoves) {
+		s.logger.Debugf("Current Moves: %+v", s.moves)
+		return s.commandError(cmd, PhaseLimit, false, ErrOverLimit)
+	}
+	if err := s.send(cmd, text, timeout); err != nil {
+		s.moves = previous
+		return err
+	}
+	switch cmd {
+	case input.TakeOff:
+		s.telemetry.setAirborne(true)
+		s.bus.Publish(event.New(event.TakeOff, sdkPublisher, s.State()))
+	case input.Land:
+		s.telemetry.setAirborne(false)
+		s.bus.Publish(event.New(event.Landing, sdkPublisher, s.State()))
+	case input.FrontFlip, input.BackFlip, input.LeftFlip, input.RightFlip:
+		s.bus.Publish(event.New(event.Flip, sdkPublisher, s.State()))
+	}
+	return nil
+}
+
+// send sends the text command to the drone and waits for the acknowledgement
+func (s *SDK) send(cmd input.Command, text string, timeout time.Duration) *CommandError {
+	s.clientMux.Lock()
+	client := s.client
+	s.clientMux.Unlock()
+	if client == nil {
+		return s.commandError(cmd, PhaseLink, true, ErrDisconnected)
+	}
+	atomic.AddInt32(&s.inFlight, 1)
+	err := client.Do(text, timeout)
+	atomic.AddInt32(&s.inFlight, -1)
+	if err == nil {
+		s.link.beat(time.Now())
+		return nil
+	}
+	if _, rejected := err.(*sdk.RejectedError); rejected {
+		// The drone has heard the command, but refused to carry it out
+		s.link.beat(time.Now())
+		return s.commandError(cmd, PhaseValidate, true, err)
+	}
+	return s.commandError(cmd, PhaseSend, err == sdk.ErrTimeout || err == sdk.ErrBusy, err)
+}
+
+// validate checks whether the command can be executed in the current state of the drone
+func (s *SDK) validate(cmd input.Command) *CommandError {
+	if cmd.IsCamera() || cmd == input.ThrowTakeOff || cmd == input.PalmLand {
+		// The text protocol cannot change the camera settings, or take off from a throw and land on a palm
+		return s.commandError(cmd, PhaseValidate, false, ErrUnsupported)
+	}
+	if cmd != input.Land && s.link.current() == Lost {
+		return s.commandError(cmd, PhaseLink, true, ErrDisconnected)
+	}
+	state := s.telemetry.snapshot()
+	if !state.Reported {
+		return nil
+	}
+	switch cmd {
+	case input.TakeOff:
+		if state.BatteryLow || state.Battery < s.limits.MinTakeOffBattery {
+			return s.commandError(cmd, PhaseValidate, false, ErrBatteryLow)
+		}
+	case input.Up:
+		if !state.Airborne {
+			return s.commandError(cmd, PhaseValidate, true, ErrNotAirborne)
+		}
+		if s.limits.MaxHeight > 0 && state.Height >= s.limits.MaxHeight {
+			return s.commandError(cmd, PhaseLimit, false, ErrOverLimit)
+		}
+	case input.Land:
+	default:
+		if !state.Airborne {
+			return s.commandError(cmd, PhaseValidate, true, ErrNotAirborne)
+		}
+	}
+	if s.fence == nil {
+		return nil
+	}
+	switch cmd {
+	case input.Forward, input.Backward, input.Left, input.Right, input.Up, input.Down:
+		position, heading, ok := s.positions.Position()
+		if !ok {
+			return s.commandError(cmd, PhaseLimit, true, ErrPositionUnknown)
+		}
+		if !s.fence.Contains(moveTo(position, heading, cmd, float64(s.distance))) {
+			return s.commandError(cmd, PhaseLimit, false, ErrOutsideFence)
+		}
+	}
+	return nil
+}
+
+// validatePad checks whether the drone can fly to a position relative to a mission pad.
+// The coordinates and the speed are checked against the limits of the SDK, which the drone would otherwise reject with a bare "error".
+func (s *SDK) validatePad(pad, x, y, z, speed int) *CommandError {
+	if !s.missionPads {
+		return s.commandError(input.None, PhaseValidate, false, ErrUnsupported)
+	}
+	if pad < 1 || pad > 8 {
+		return s.commandError(input.None, PhaseValidate, false, fmt.Errorf("invalid mission pad m%d", pad))
+	}
+	if speed < 10 || speed > 100 {
+		return s.commandError(input.None, PhaseValidate, false, fmt.Errorf("invalid speed %d cm/s, it must be between 10 and 100", speed))
+	}
+	for _, value := range []int{x, y, z} {
+		if value < -500 || value > 500 {
+			return s.commandError(input.None, PhaseValidate, false, fmt.Errorf("invalid position (%d, %d, %d), each coordinate must be between -500 and 500", x, y, z))
+		}
+	}
+	if abs(x) <= 20 && abs(y) <= 20 && abs(z) <= 20 {
+		return s.commandError(input.None, PhaseValidate, false, fmt.Errorf("invalid position (%d, %d, %d), at least one coordinate must be more than 20 away from the pad", x, y, z))
+	}
+	if s.link.current() == Lost {
+		return s.commandError(input.None, PhaseLink, true, ErrDisconnected)
+	}
+	if !s.telemetry.snapshot().Airborne {
+		return s.commandError(input.None, PhaseValidate, true, ErrNotAirborne)
+	}
+	if s.fence == nil {
+		return nil
+	}
+	origin, known := s.layout[pad]
+	if !known {
+		return s.commandError(input.None, PhaseLimit, false, ErrPositionUnknown)
+	}
+	target := Position{X: origin.X + float64(x), Y: origin.Y + float64(y), Z: origin.Z + float64(z)}
+	if !s.fence.Contains(target) {
+		return s.commandError(input.None, PhaseLimit, false, ErrOutsideFence)
+	}
+	return nil
+}
+
+// stateReceived records the state the drone broadcasts. The state doubles as the heartbeat of the drone.
+func (s *SDK) stateReceived(raw sdk.State) {
+	s.link.beat(time.Now())
+	state := DroneState{
+		Reported: true,
+		// The height is reported relative to the take off point, so it's zero on the ground
+		Airborne:   raw.Height > 0,
+		Height:     int16(raw.Height / 10),
+		Speed:      int16(math.Hypot(float64(raw.SpeedX), float64(raw.SpeedY))),
+		Battery:    int8(raw.Battery),
+		BatteryLow: raw.Battery < sdkLowBattery,
+	}
+	if raw.PadDetected() {
+		state.Pad = MissionPad{ID: raw.MissionPad, X: raw.PadX, Y: raw.PadY, Z: raw.PadZ, Yaw: raw.PadYaw}
+	}
+	previous := s.telemetry.set(state)
+	if state.Pad.ID != previous.Pad.ID {
+		s.bus.Publish(event.New(event.MissionPad, sdkPublisher, state.Pad))
+	}
+	if state.BatteryLow && !previous.BatteryLow {
+		s.logger.Warnf("Battery is low %d%%", state.Battery)
+		s.bus.Publish(event.New(event.BatteryLow, sdkPublisher, s.State()))
+	}
+	landing := s.limits.LandBattery
+	if state.Airborne && state.Battery < landing && (!previous.Reported || previous.Battery >= landing) {
+		s.logger.Warnf("Battery is below %d%%, landing", landing)
+		go s.sendAsync("land", s.flightTimeout)
+	}
+}
+
+// supervise keeps the drone in the SDK mode while idle and reports the changes in the health of the link.
+// The drone keeps broadcasting its state once the link recovers, so there is no need to reconnect.
+func (s *SDK) supervise(stop <-chan interface{}) {
+	ticker := time.NewTicker(linkCheckInterval)
+	defer ticker.Stop()
+	lastPing := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			// The drone does not need to be kept alive while it's carrying out a command.
+			// The ping would also hold up the next command until the drone has responded to it.
+			if now.Sub(lastPing) >= sdkKeepAlive && atomic.LoadInt32(&s.inFlight) == 0 {
+				lastPing = now
+				go s.ping()
+			}
+			previous, current := s.link.evaluate(now)
+			if previous == current {
+				continue
+			}
+			s.logger.Infof("Drone: Link %s", current)
+			s.bus.Publish(event.New(event.ConnectionChanged, sdkPublisher, current))
+			if current != Lost {
+				continue
+			}
+			s.bus.Publish(event.New(event.Disconnected, sdkPublisher, s.State()))
+			if s.telemetry.snapshot().Airborne {
+				action := s.link.policy.Failsafe
+				s.logger.Warnf("Drone: Failsafe %s", action)
+				s.bus.Publish(event.New(event.Failsafe, sdkPublisher, action))
+				if action == FailsafeLand {
+					go s.sendAsync("land", s.flightTimeout)
+				} else {
+					go s.sendAsync("stop", s.timeout)
+				}
+			}
+		}
+	}
+}
+
+// ping asks the drone for the battery, which keeps the drone in the SDK mode
+func (s *SDK) ping() {
+	s.clientMux.Lock()
+	client := s.client
+	s.clientMux.Unlock()
+	if client == nil {
+		return
+	}
+	if _, err := client.Read("battery?", 0); err != nil {
+		s.logger.Debugf("Drone: Keep alive failed: %s", err)
+		return
+	}
+	s.link.beat(time.Now())
+}
+
+// sendAsync sends an urgent command (ie. the failsafe) outside of the command loop, without waiting for the drone
+// to respond to the commands which have timed out, and logs the failures
+func (s *SDK) sendAsync(command string, timeout time.Duration) {
+	s.clientMux.Lock()
+	client := s.client
+	s.clientMux.Unlock()
+	if client == nil {
+		return
+	}
+	response, err := client.Interrupt(command, timeout)
+	if err == nil && !strings.EqualFold(response, "ok") {
+		err = &sdk.RejectedError{Command: command, Response: response}
+	}
+	if err != nil {
+		s.logger.Errorf("Drone: %s failed: %s", command, err)
+	}
+}
+
+func (s *SDK) acknowledge(id uint64, cmd input.Command, outcome input.Outcome, reason error) {
+	result := input.Result{ID: id, Command: cmd, Outcome: outcome, Reason: reason}
+	switch outcome {
+	case input.Executed:
+		s.bus.Publish(event.New(event.CommandExecuted, sdkPublisher, result))
+	case input.Rejected:
+		s.bus.Publish(event.New(event.CommandRejected, sdkPublisher, result))
+	case input.Failed:
+		s.bus.Publish(event.New(event.CommandFailed, sdkPublisher, result))
+	}
+	if s.acknowledger != nil {
+		s.acknowledger.Acknowledge(result)
+	}
+}
+
+func (s *SDK) printCommand(command input.Command) {
+	s.logger.Log(logging.Info, logging.Fields{"command": command}, "Drone: %s Command Received", command)
+}
+
+func (s *SDK) commandError(cmd input.Command, phase Phase, retryable bool, err error) *CommandError {
+	return &CommandError{
+		Command:   cmd,
+		Phase:     phase,
+		Retryable: retryable,
+		State:     s.State(),
+		Err:       err,
+	}
+}
+
+func clamp(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,145 @@
+package robot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xitonix/gophobotics/fake"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/sdk"
+)
+
+// dialSDK connects to a fake drone which does not report its state, so that only the drone validates the commands
+func dialSDK(t *testing.T, timeout time.Duration) (*SDK, *fake.SDK) {
+	t.Helper()
+	drone, err := fake.NewSDK("127.0.0.1:0", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = drone.Close() })
+	s := NewSDK(TelloAddress{IP: "127.0.0.1", CommandPort: drone.Port(), LocalPort: freePort(t)}, 50, 0, newTestLogger())
+	s.timeout, s.flightTimeout = timeout, timeout
+	if err := s.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s, drone
+}
+
+func TestSDKExecutesCommands(t *testing.T) {
+	s, drone := dialSDK(t, time.Second)
+
+	for _, cmd := range []input.Command{input.TakeOff, input.Forward, input.RotateRight, input.Land} {
+		if err := s.Execute(cmd); err != nil {
+			t.Fatalf("%s failed: %s", cmd, err)
+		}
+	}
+	commands := drone.Commands()
+	if last := commands[len(commands)-1]; last != "land" {
+		t.Errorf("Expected the drone to land, got %q", last)
+	}
+}
+
+func TestSDKReportsTheRejectedCommands(t *testing.T) {
+	s, _ := dialSDK(t, time.Second)
+
+	err := s.Execute(input.Forward)
+	if err == nil || err.Phase != PhaseValidate || !err.Retryable {
+		t.Fatalf("Expected a retryable validation error, got %v", err)
+	}
+	var rejected *sdk.RejectedError
+	if !errors.As(err, &rejected) {
+		t.Errorf("Expected the response of the drone, got %v", err)
+	}
+}
+
+func TestSDKTimeout(t *testing.T) {
+	s, drone := dialSDK(t, 100*time.Millisecond)
+	drone.SetSilent(true)
+
+	err := s.Execute(input.TakeOff)
+	if err == nil || err.Phase != PhaseSend || !errors.Is(err, sdk.ErrTimeout) {
+		t.Fatalf("Expected a timeout, got %v", err)
+	}
+	if s.State().Airborne {
+		t.Error("Expected the drone not to be airborne after the take off has timed out")
+	}
+}
+
+func TestSDKDoesNotTakeLateResponses(t *testing.T) {
+	s, drone := dialSDK(t, 200*time.Millisecond)
+	// The drone rejects the move after it has timed out, while the take off would be waiting for its own response
+	drone.SetDelay(300 * time.Millisecond)
+
+	if err := s.Execute(input.Forward); err == nil || !errors.Is(err, sdk.ErrTimeout) {
+		t.Fatalf("Expected a timeout, got %v", err)
+	}
+	s.timeout, s.flightTimeout = 2*time.Second, 2*time.Second
+	if err := s.Execute(input.TakeOff); err != nil {
+		t.Fatalf("Expected the take off to succeed, got %v", err)
+	}
+}
+
+func TestValidatePad(t *testing.T) {
+	testCases := []struct {
+		title         string
+		pad, x, y, z  int
+		speed         int
+		outOfSDKRange bool
+	}{
+		{title: "within the limits", pad: 1, z: 60, speed: 50},
+		{title: "invalid pad", pad: 9, z: 60, speed: 50, outOfSDKRange: true},
+		{title: "too slow", pad: 1, z: 60, speed: 5, outOfSDKRange: true},
+		{title: "too fast", pad: 1, z: 60, speed: 101, outOfSDKRange: true},
+		{title: "too far", pad: 1, x: 501, z: 60, speed: 50, outOfSDKRange: true},
+		{title: "too far behind", pad: 1, y: -501, z: 60, speed: 50, outOfSDKRange: true},
+		{title: "too close to the pad", pad: 1, x: 20, y: -20, z: 20, speed: 50, outOfSDKRange: true},
+		{title: "far enough on one axis", pad: 1, x: -21, y: 10, z: 10, speed: 50},
+	}
+	s := NewSDK(TelloAddress{}, 50, 0, newTestLogger())
+	s.SetMissionPads(true)
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			err := s.validatePad(tc.pad, tc.x, tc.y, tc.z, tc.speed)
+			if err == nil {
+				t.Fatal("Expected an error, the drone is not airborne")
+			}
+			if tc.outOfSDKRange && (err.Phase != PhaseValidate || errors.Is(err, ErrNotAirborne)) {
+				t.Errorf("Expected the SDK limits to be checked first, got %v", err)
+			}
+			if !tc.outOfSDKRange && !errors.Is(err, ErrNotAirborne) {
+				t.Errorf("Expected the position to be within the SDK limits, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSDKDrainsTheSourceWhenTheDroneIsUnreachable(t *testing.T) {
+	// Nothing listens on the port, so the drone never enters the SDK mode
+	s := NewSDK(TelloAddress{IP: "127.0.0.1", CommandPort: freePort(t), LocalPort: freePort(t)}, 50, 0, newTestLogger())
+	source := newScriptedSource()
+	connected := make(chan error, 1)
+	go func() {
+		connected <- s.Connect(source)
+	}()
+
+	for _, cmd := range []input.Command{input.TakeOff, input.Exit} {
+		result := source.send(t, cmd)
+		if result.Outcome != input.Failed || !errors.Is(result.Reason, ErrDisconnected) {
+			t.Errorf("Expected %s to fail, got %s: %v", cmd, result.Outcome, result.Reason)
+		}
+	}
+	close(source.commands)
+	select {
+	case err := <-connected:
+		if err == nil {
+			t.Error("Expected an error connecting to the drone")
+		}
+	case <-time.After(15 * time.Second):
+		t.Fatal("Connect has not returned once the source has been closed")
+	}
+	if _, open := <-s.Errors(); open {
+		t.Error("Expected the errors channel to be closed")
+	}
+}
@@ -0,0 +1,104 @@
+package robot
+
+import (
+	"math"
+	"sync"
+
+	"gobot.io/x/gobot/platforms/dji/tello"
+)
+
+// DroneState is a snapshot of the drone at a point in time
+type DroneState struct {
+	// Connection is the health of the link to the drone
+	Connection ConnectionState
+	// Reported is false until the first flight data has been received from the drone.
+	// None of the other fields are meaningful before that.
+	Reported bool
+	// Airborne is true if the drone is flying
+	Airborne bool
+	// Height is the height of the drone in decimetres
+	Height int16
+	// Speed is the horizontal speed of the drone in decimetres per second
+	Speed int16
+	// Battery is the remaining battery percentage
+	Battery int8
+	// BatteryLow is true if the drone considers the battery low
+	BatteryLow bool
+	// WifiStrength is the strength of the Wi-Fi signal the drone receives (0-100). Zero means it has not been reported.
+	WifiStrength int8
+	// VideoBitRate is the bit rate of the video encoder of the drone
+	VideoBitRate VideoBitRate
+	// Exposure is the exposure level of the camera
+	Exposure Exposure
+	// AutoExposure is true while the exposure is adapted to the light
+	AutoExposure bool
+	// WideVideo is true if the camera has been switched to the wide (16:9) video
+	WideVideo bool
+	// Pad is the mission pad the drone is flying over. Only the Tello EDU drones can detect the pads.
+	Pad MissionPad
+}
+
+// MissionPad is a mission pad detected by the drone
+type MissionPad struct {
+	// ID is the number printed on the pad (1-8). Zero means no pad has been detected.
+	ID int
+	// X, Y and Z are the position of the drone relative to the pad in centimetres.
+	// X points to the front of the pad, Y to the left and Z up.
+	X, Y, Z int
+	// Yaw is the heading of the drone relative to the pad in degrees, measured clockwise
+	Yaw int
+}
+
+// Detected returns true if the drone is flying over the pad
+func (p MissionPad) Detected() bool {
+	return p.ID > 0
+}
+
+// telemetry keeps the latest flight data received from the drone
+type telemetry struct {
+	mux   sync.Mutex
+	state DroneState
+}
+
+// update records the latest flight data and returns the previous state
+func (t *telemetry) update(fd *tello.FlightData) DroneState {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	previous := t.state
+	t.state.Reported = true
+	t.state.Airborne = fd.EmSky
+	t.state.Height = fd.Height
+	t.state.Speed = int16(math.Hypot(float64(fd.NorthSpeed), float64(fd.EastSpeed)))
+	t.state.Battery = fd.BatteryPercentage
+	t.state.BatteryLow = fd.BatteryLow
+	return previous
+}
+
+// set replaces the state and returns the previous one
+func (t *telemetry) set(state DroneState) DroneState {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	previous := t.state
+	t.state = state
+	return previous
+}
+
+// setWifi records the strength of the Wi-Fi signal, which the drone reports separately from the flight data
+func (t *telemetry) setWifi(strength int8) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.state.WifiStrength = strength
+}
+
+// setAirborne records a take off or a landing before the drone reports it
+func (t *telemetry) setAirborne(airborne bool) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.state.Airborne = airborne
+}
+
+func (t *telemetry) snapshot() DroneState {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.state
+}
@@ -0,0 +1,486 @@
+package robot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	smerrony "github.com/SMerrony/tello"
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
+)
+
+// swarmPublisher is the name the Swarm robot publishes the events under
+const swarmPublisher = "swarm"
+
+// swarmBatteryInterval is how often the batteries of the drones are checked
+const swarmBatteryInterval = time.Second
+
+// SwarmMember is a drone of the swarm
+type SwarmMember struct {
+	// Name identifies the drone in the swarm
+	Name string
+	// Address is where the drone can be reached. Every member needs its own local port.
+	Address TelloAddress
+	// Groups are the names of the groups the drone belongs to
+	Groups []string
+}
+
+// SwarmError is the error a member of the swarm has reported
+type SwarmError struct {
+	// Member is the name of the drone
+	Member string
+	// Err is the underlying error
+	Err error
+}
+
+func (e *SwarmError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Member, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *SwarmError) Unwrap() error {
+	return e.Err
+}
+
+// Swarm implements the Robot interface and flies several Tello drones from one source.
+//
+// The commands are broadcast to all the drones unless the swarm has been targeted at specific drones or groups.
+// Unlike the Tello robot, each member talks to its own address, so the drones need to be in station mode,
+// connected to the same network as the host.
+// The maximum number of moves and the safety limits apply to each drone on its own. The link is not supervised:
+// a drone which cannot hear the host hovers on its own.
+type Swarm struct {
+	members          []*swarmDrone
+	move             int
+	maxNumberOfMoves int
+	limits           Limits
+	errors           chan error
+	logger           *logging.Logger
+	bus              *event.Bus
+	stop             chan interface{}
+	wg               sync.WaitGroup
+
+	mux     sync.Mutex
+	targets []*swarmDrone
+}
+
+type swarmDrone struct {
+	SwarmMember
+	drone *smerrony.Tello
+	// moves is only touched by one command at a time
+	moves moves
+	// lowBattery is true once the drone has been landed because of its battery
+	lowBattery bool
+}
+
+// NewSwarm creates a new swarm of Tello drones
+func NewSwarm(members []SwarmMember, move, maxNumberOfMoves int, logger *logging.Logger) (*Swarm, error) {
+	if len(members) == 0 {
+		return nil, fmt.Errorf("the swarm needs at least one drone")
+	}
+	names := make(map[string]bool)
+	ports := make(map[int]string)
+	drones := make([]*swarmDrone, 0, len(members))
+	for _, m := range members {
+		if m.Name == "" {
+			return nil, fmt.Errorf("the swarm members need a name")
+		}
+		if names[m.Name] {
+			return nil, fmt.Errorf("duplicate swarm member %q", m.Name)
+		}
+		if other, ok := ports[m.Address.LocalPort]; ok {
+			return nil, fmt.Errorf("%s and %s cannot share the local port %d", other, m.Name, m.Address.LocalPort)
+		}
+		names[m.Name] = true
+		ports[m.Address.LocalPort] = m.Name
+		drones = append(drones, &swarmDrone{SwarmMember: m, drone: new(smerrony.Tello)})
+	}
+	for _, d := range drones {
+		for _, g := range d.Groups {
+			if names[g] {
+				return nil, fmt.Errorf("the group %q has the same name as a drone", g)
+			}
+		}
+	}
+	return &Swarm{
+		members:          drones,
+		move:             move,
+		maxNumberOfMoves: maxNumberOfMoves,
+		errors:           make(chan error),
+		logger:           logger.With(logging.Fields{"robot": swarmPublisher}),
+		targets:          drones,
+	}, nil
+}
+
+// Errors returns the errors reported by all the members of the swarm as *SwarmError values.
+// MAKE SURE you always read from this channel before calling the Connect method to avoid deadlock
+func (s *Swarm) Errors() <-chan error {
+	return s.errors
+}
+
+// SetLimits sets the safety limits each drone of the swarm is held to. It must be called before Connect or Dial.
+func (s *Swarm) SetLimits(limits Limits) {
+	s.limits = limits
+}
+
+// SetEventBus sets the bus the swarm publishes the command outcomes to.
+// It must be called before Connect or Dial.
+func (s *Swarm) SetEventBus(bus *event.Bus) {
+	s.bus = bus
+}
+
+// Target routes the following commands to the named drones and groups only.
+// Calling it without any names broadcasts the commands to the whole swarm again.
+func (s *Swarm) Target(names ...string) error {
+	targets := s.members
+	if len(names) > 0 {
+		targets = nil
+		for _, name := range names {
+			matched := false
+			for _, d := range s.members {
+				if d.Name == name || contains(d.Groups, name) {
+					matched = true
+					if !containsDrone(targets, d) {
+						targets = append(targets, d)
+					}
+				}
+			}
+			if !matched {
+				return fmt.Errorf("no drone or group named %q in the swarm", name)
+			}
+		}
+	}
+	s.mux.Lock()
+	s.targets = targets
+	s.mux.Unlock()
+	return nil
+}
+
+// States returns a snapshot of the state of every drone in the swarm by name
+func (s *Swarm) States() map[string]DroneState {
+	states := make(map[string]DroneState, len(s.members))
+	for _, d := range s.members {
+		states[d.Name] = d.state()
+	}
+	return states
+}
+
+// Connect connects to all the drones and blocks until the source's Commands channel is closed.
+// The drones which cannot be reached are reported through the Errors channel and left out.
+func (s *Swarm) Connect(source input.Source) error {
+	defer close(s.errors)
+
+	unreachable := s.dial()
+	s.start()
+	for _, d := range unreachable {
+		s.errors <- &SwarmError{Member: d.Name, Err: s.commandError(d, input.None, PhaseLink, true, ErrDisconnected)}
+	}
+	if len(unreachable) == len(s.members) {
+		err := fmt.Errorf("none of the drones in the swarm could be reached")
+		s.logger.Errorf("Swarm: %s", err)
+		s.land()
+		drain(source, ErrDisconnected)
+		return err
+	}
+
+	ack, _ := source.(input.Acknowledger)
+	var id uint64
+	for cmd := range source.Commands() {
+		id++
+		if cmd == input.None {
+			continue
+		}
+		outcome, reason := s.execute(cmd)
+		result := input.Result{ID: id, Command: cmd, Outcome: outcome, Reason: reason}
+		s.publish(result)
+		if ack != nil {
+			ack.Acknowledge(result)
+		}
+		if cmd == input.Exit {
+			break
+		}
+	}
+
+	s.land()
+	return nil
+}
+
+// Dial connects to all the drones without reading any commands, so that they can be driven individually using Perform.
+// An error is returned if any of the drones cannot be reached.
+func (s *Swarm) Dial() error {
+	unreachable := s.dial()
+	s.start()
+	if len(unreachable) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(unreachable))
+	for _, d := range unreachable {
+		names = append(names, d.Name)
+	}
+	s.Close()
+	return fmt.Errorf("could not reach %s", strings.Join(names, ", "))
+}
+
+// Perform executes the command on the named drone. The drones must have been connected using Dial.
+func (s *Swarm) Perform(name string, cmd input.Command) error {
+	for _, d := range s.members {
+		if d.Name == name {
+			if err := s.executeOn(d, cmd); err != nil {
+				return err
+			}
+			s.logger.Log(logging.Debug, logging.Fields{"member": name, "command": cmd}, "%s: %s Command Received", name, cmd)
+			return nil
+		}
+	}
+	return fmt.Errorf("no drone named %q in the swarm", name)
+}
+
+// Close lands all the drones and closes the connections. It only needs to be called after Dial.
+func (s *Swarm) Close() {
+	s.land()
+}
+
+// dial connects to all the drones at the same time and returns the ones which could not be reached
+func (s *Swarm) dial() []*swarmDrone {
+	var wg sync.WaitGroup
+	connected := make([]bool, len(s.members))
+	for i, d := range s.members {
+		wg.Add(1)
+		go func(i int, d *swarmDrone) {
+			defer wg.Done()
+			a := d.Address
+			if err := d.drone.ControlConnect(a.IP, a.CommandPort, a.LocalPort); err != nil {
+				s.logger.Log(logging.Error, logging.Fields{"member": d.Name}, "Failed to connect to %s: %s", d.Name, err)
+				return
+			}
+			connected[i] = true
+			s.logger.Log(logging.Info, logging.Fields{"member": d.Name}, "Connected to %s", d.Name)
+		}(i, d)
+	}
+	wg.Wait()
+
+	var unreachable []*swarmDrone
+	for i, d := range s.members {
+		if !connected[i] {
+			unreachable = append(unreachable, d)
+		}
+	}
+	return unreachable
+}
+
+// execute sends the command to the targeted drones at the same time
+func (s *Swarm) execute(cmd input.Command) (input.Outcome, error) {
+	s.mux.Lock()
+	targets := s.targets
+	s.mux.Unlock()
+
+	if cmd == input.Exit {
+		s.logger.Log(logging.Info, logging.Fields{"command": cmd}, "Swarm: %s Command Received", cmd)
+		return input.Executed, nil
+	}
+
+	var wg sync.WaitGroup
+	failures := make([]error, len(targets))
+	for i, d := range targets {
+		wg.Add(1)
+		go func(i int, d *swarmDrone) {
+			defer wg.Done()
+			failures[i] = s.executeOn(d, cmd)
+		}(i, d)
+	}
+	wg.Wait()
+
+	var first error
+	outcome := input.Executed
+	for _, err := range failures {
+		if err == nil {
+			continue
+		}
+		if first == nil {
+			first = err
+		}
+		// The command has failed if it could not be sent to any of the drones, like the Tello robot does
+		var cmdErr *CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Phase == PhaseSend {
+			outcome = input.Failed
+		} else if outcome == input.Executed {
+			outcome = input.Rejected
+		}
+		s.errors <- err
+	}
+	if first != nil {
+		return outcome, first
+	}
+	s.logger.Log(logging.Info, logging.Fields{"command": cmd, "drones": len(targets)}, "Swarm: %s Command Received", cmd)
+	return input.Executed, nil
+}
+
+func (s *Swarm) executeOn(d *swarmDrone, cmd input.Command) error {
+	if !d.drone.ControlConnected() {
+		// The drone has been left out or has been disconnected, so the command cannot be sent to it
+		return &SwarmError{Member: d.Name, Err: s.commandError(d, cmd, PhaseSend, true, ErrDisconnected)}
+	}
+	if err := s.validateOn(d, cmd); err != nil {
+		return &SwarmError{Member: d.Name, Err: err}
+	}
+	switch cmd {
+	case input.TakeOff:
+		d.drone.TakeOff()
+		return nil
+	case input.Land:
+		d.drone.Land()
+		return nil
+	case input.Forward:
+		d.drone.Forward(s.move)
+	case input.Backward:
+		d.drone.Backward(s.move)
+	case input.Left:
+		d.drone.Left(s.move)
+	case input.Right:
+		d.drone.Right(s.move)
+	case input.Up:
+		d.drone.Up(s.move)
+	case input.Down:
+		d.drone.Down(s.move)
+	case input.RotateRight:
+		d.drone.Clockwise(s.move)
+	case input.RotateLeft:
+		d.drone.Anticlockwise(s.move)
+	default:
+		return &SwarmError{Member: d.Name, Err: s.commandError(d, cmd, PhaseValidate, false, ErrUnsupported)}
+	}
+	time.Sleep(500 * time.Millisecond)
+	// Hover releases all the sticks, including the rotation
+	d.drone.Hover()
+	return nil
+}
+
+// validateOn checks whether the command can be executed by the drone, according to its own state and moves
+func (s *Swarm) validateOn(d *swarmDrone, cmd input.Command) *CommandError {
+	state := d.state()
+	switch cmd {
+	case input.TakeOff:
+		if state.Reported && (state.BatteryLow || state.Battery < s.limits.MinTakeOffBattery) {
+			return s.commandError(d, cmd, PhaseValidate, false, ErrBatteryLow)
+		}
+	case input.Up:
+		if state.Reported && s.limits.MaxHeight > 0 && state.Height >= s.limits.MaxHeight {
+			return s.commandError(d, cmd, PhaseLimit, false, ErrOverLimit)
+		}
+	}
+	if d.moves.register(cmd, s.maxNumberOfMoves) {
+		return s.commandError(d, cmd, PhaseLimit, false, ErrOverLimit)
+	}
+	return nil
+}
+
+// start watches the batteries of the drones until they are landed
+func (s *Swarm) start() {
+	s.stop = make(chan interface{})
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.superviseBatteries(s.stop)
+	}()
+}
+
+// superviseBatteries lands each drone once its battery drops below the landing threshold
+func (s *Swarm) superviseBatteries(stop <-chan interface{}) {
+	ticker := time.NewTicker(swarmBatteryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			landing := s.limits.LandBattery
+			for _, d := range s.members {
+				if !d.drone.ControlConnected() {
+					continue
+				}
+				state := d.state()
+				low := state.Reported && state.Battery < landing
+				if low && state.Airborne && !d.lowBattery {
+					s.logger.Log(logging.Warn, logging.Fields{"member": d.Name}, "%s: Battery is below %d%%, landing", d.Name, landing)
+					s.bus.Publish(event.New(event.BatteryLow, swarmPublisher, state))
+					d.drone.Land()
+				}
+				d.lowBattery = low
+			}
+		}
+	}
+}
+
+// land lands all the drones and closes the connections
+func (s *Swarm) land() {
+	if s.stop != nil {
+		close(s.stop)
+		s.wg.Wait()
+		s.stop = nil
+	}
+	for _, d := range s.members {
+		if !d.drone.ControlConnected() {
+			continue
+		}
+		d.drone.Land()
+		d.drone.ControlDisconnect()
+	}
+}
+
+func (s *Swarm) publish(result input.Result) {
+	switch result.Outcome {
+	case input.Executed:
+		s.bus.Publish(event.New(event.CommandExecuted, swarmPublisher, result))
+	case input.Rejected:
+		s.bus.Publish(event.New(event.CommandRejected, swarmPublisher, result))
+	case input.Failed:
+		s.bus.Publish(event.New(event.CommandFailed, swarmPublisher, result))
+	}
+}
+
+func (s *Swarm) commandError(d *swarmDrone, cmd input.Command, phase Phase, retryable bool, err error) *CommandError {
+	return &CommandError{
+		Command:   cmd,
+		Phase:     phase,
+		Retryable: retryable,
+		State:     d.state(),
+		Err:       err,
+	}
+}
+
+func (d *swarmDrone) state() DroneState {
+	state := DroneState{Connection: Lost}
+	if d.drone.ControlConnected() {
+		state.Connection = Connected
+	}
+	fd := d.drone.GetFlightData()
+	// The flight data is all zero until the first status message arrives
+	state.Reported = fd.BatteryPercentage > 0 || fd.WifiStrength > 0
+	state.Airborne = fd.Flying
+	state.Height = fd.Height
+	state.Battery = fd.BatteryPercentage
+	state.BatteryLow = fd.BatteryLow
+	return state
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDrone(list []*swarmDrone, d *swarmDrone) bool {
+	for _, item := range list {
+		if item == d {
+			return true
+		}
+	}
+	return false
+}
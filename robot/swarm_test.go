@@ -0,0 +1,255 @@
+package robot
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/xitonix/gophobotics/fake"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
+)
+
+// scriptedSource sends the commands one at a time and waits for each of them to be acknowledged
+type scriptedSource struct {
+	commands chan input.Command
+	results  chan input.Result
+}
+
+func newScriptedSource() *scriptedSource {
+	return &scriptedSource{
+		commands: make(chan input.Command),
+		results:  make(chan input.Result, 1),
+	}
+}
+
+func (s *scriptedSource) Commands() <-chan input.Command {
+	return s.commands
+}
+
+func (s *scriptedSource) Acknowledge(result input.Result) {
+	s.results <- result
+}
+
+func (s *scriptedSource) send(t *testing.T, cmd input.Command) input.Result {
+	t.Helper()
+	s.commands <- cmd
+	select {
+	case result := <-s.results:
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s has not been acknowledged", cmd)
+		return input.Result{}
+	}
+}
+
+func newTestLogger() *logging.Logger {
+	return logging.New(logging.Debug, logging.NewMemory())
+}
+
+// freePort returns a local UDP port which is not in use
+func freePort(t *testing.T) int {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// startSwarm starts a fake drone for each name and connects a swarm to them. The drones named in down are stopped before connecting.
+func startSwarm(t *testing.T, names []string, down ...string) (*Swarm, map[string]*fake.Tello, *scriptedSource, <-chan error) {
+	t.Helper()
+	drones := make(map[string]*fake.Tello)
+	var members []SwarmMember
+	for i, name := range names {
+		drone, err := fake.NewTello("127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = drone.Close() })
+		drones[name] = drone
+		members = append(members, SwarmMember{
+			Name:    name,
+			Address: TelloAddress{IP: "127.0.0.1", CommandPort: drone.Port(), LocalPort: freePort(t)},
+			Groups:  []string{[]string{"left", "right"}[i%2]},
+		})
+	}
+	for _, name := range down {
+		_ = drones[name].Close()
+	}
+
+	swarm, err := NewSwarm(members, 30, 0, newTestLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 100)
+	go func() {
+		for err := range swarm.Errors() {
+			errs <- err
+		}
+		close(errs)
+	}()
+	source := newScriptedSource()
+	go func() {
+		if err := swarm.Connect(source); err != nil {
+			t.Error(err)
+		}
+	}()
+	return swarm, drones, source, errs
+}
+
+// waitFor polls the condition until it's met or the timeout expires
+func waitFor(t *testing.T, description string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", description)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestSwarmBroadcastsToAllTheDrones(t *testing.T) {
+	names := []string{"alpha", "bravo", "charlie"}
+	_, drones, source, _ := startSwarm(t, names)
+
+	for _, cmd := range []input.Command{input.TakeOff, input.Forward, input.Land} {
+		if result := source.send(t, cmd); result.Outcome != input.Executed {
+			t.Fatalf("Expected %s to be executed, got %s: %v", cmd, result.Outcome, result.Reason)
+		}
+	}
+	for _, name := range names {
+		drone := drones[name]
+		if !drone.Connected() {
+			t.Errorf("Expected %s to be connected", name)
+		}
+		waitFor(t, name+" to take off and land", func() bool {
+			return drone.Received(fake.TakeOffMessage) == 1 && drone.Received(fake.LandMessage) == 1
+		})
+	}
+	source.send(t, input.Exit)
+}
+
+func TestSwarmTargetsGroups(t *testing.T) {
+	names := []string{"alpha", "bravo", "charlie"}
+	swarm, drones, source, _ := startSwarm(t, names)
+
+	if err := swarm.Target("right"); err != nil {
+		t.Fatal(err)
+	}
+	if result := source.send(t, input.TakeOff); result.Outcome != input.Executed {
+		t.Fatalf("Expected the take off to be executed, got %s", result.Outcome)
+	}
+	waitFor(t, "bravo to take off", func() bool { return drones["bravo"].Received(fake.TakeOffMessage) == 1 })
+	for _, name := range []string{"alpha", "charlie"} {
+		if n := drones[name].Received(fake.TakeOffMessage); n != 0 {
+			t.Errorf("Expected %s not to take off, got %d take off messages", name, n)
+		}
+	}
+	if err := swarm.Target("nobody"); err == nil {
+		t.Error("Expected an error targeting an unknown group")
+	}
+	source.send(t, input.Exit)
+}
+
+func TestSwarmReportsTheUnreachableDrones(t *testing.T) {
+	names := []string{"alpha", "bravo"}
+	_, drones, source, errs := startSwarm(t, names, "bravo")
+
+	// The unreachable drone is reported while connecting
+	select {
+	case err := <-errs:
+		var swarmErr *SwarmError
+		if !errors.As(err, &swarmErr) || swarmErr.Member != "bravo" || !errors.Is(err, ErrDisconnected) {
+			t.Fatalf("Expected bravo to be reported as disconnected, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("The unreachable drone has not been reported")
+	}
+
+	result := source.send(t, input.TakeOff)
+	if result.Outcome != input.Failed {
+		t.Errorf("Expected the command to fail, got %s", result.Outcome)
+	}
+	var cmdErr *CommandError
+	if !errors.As(result.Reason, &cmdErr) || cmdErr.Phase != PhaseSend {
+		t.Errorf("Expected a send error, got %v", result.Reason)
+	}
+	waitFor(t, "alpha to take off", func() bool { return drones["alpha"].Received(fake.TakeOffMessage) == 1 })
+
+	result = source.send(t, input.FrontFlip)
+	if result.Outcome != input.Failed {
+		t.Errorf("Expected the unsupported command to fail on the unreachable drone, got %s", result.Outcome)
+	}
+	source.send(t, input.Exit)
+}
+
+func TestNewSwarmRejectsSharedLocalPorts(t *testing.T) {
+	members := []SwarmMember{
+		{Name: "alpha", Address: TelloAddress{IP: "127.0.0.1", CommandPort: 8889, LocalPort: 8890}},
+		{Name: "bravo", Address: TelloAddress{IP: "127.0.0.1", CommandPort: 8889, LocalPort: 8890}},
+	}
+	if _, err := NewSwarm(members, 30, 0, newTestLogger()); err == nil {
+		t.Error("Expected an error for the shared local port")
+	}
+}
+
+func TestSwarmDrainsTheSourceWhenNoDroneIsReachable(t *testing.T) {
+	members := []SwarmMember{
+		{Name: "alpha", Address: TelloAddress{IP: "127.0.0.1", CommandPort: freePort(t), LocalPort: freePort(t)}},
+		{Name: "bravo", Address: TelloAddress{IP: "127.0.0.1", CommandPort: freePort(t), LocalPort: freePort(t)}},
+	}
+	swarm, err := NewSwarm(members, 30, 0, newTestLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for range swarm.Errors() {
+		}
+	}()
+	source := newScriptedSource()
+	connected := make(chan error, 1)
+	go func() {
+		connected <- swarm.Connect(source)
+	}()
+
+	for _, cmd := range []input.Command{input.TakeOff, input.Exit} {
+		if result := source.send(t, cmd); result.Outcome != input.Failed {
+			t.Errorf("Expected %s to fail, got %s", cmd, result.Outcome)
+		}
+	}
+	close(source.commands)
+	select {
+	case err := <-connected:
+		if err == nil {
+			t.Error("Expected an error connecting to the swarm")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Connect has not returned once the source has been closed")
+	}
+}
+
+func TestSwarmLimitsTheMovesOfEachDrone(t *testing.T) {
+	names := []string{"alpha", "bravo"}
+	swarm, _, source, _ := startSwarm(t, names)
+	swarm.maxNumberOfMoves = 2
+
+	if result := source.send(t, input.TakeOff); result.Outcome != input.Executed {
+		t.Fatalf("Expected the take off to be executed, got %s", result.Outcome)
+	}
+	if result := source.send(t, input.Forward); result.Outcome != input.Executed {
+		t.Fatalf("Expected the first move to be executed, got %s: %v", result.Outcome, result.Reason)
+	}
+	result := source.send(t, input.Forward)
+	if result.Outcome != input.Rejected || !errors.Is(result.Reason, ErrOverLimit) {
+		t.Errorf("Expected the second move to be over the limit, got %s: %v", result.Outcome, result.Reason)
+	}
+	if result := source.send(t, input.Backward); result.Outcome != input.Executed {
+		t.Errorf("Expected the opposite move to be executed, got %s: %v", result.Outcome, result.Reason)
+	}
+	source.send(t, input.Exit)
+}
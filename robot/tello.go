@@ -1,12 +1,17 @@
 package robot
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/xitonix/gophobotics/event"
 	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
 	"gobot.io/x/gobot"
 	"gobot.io/x/gobot/platforms/dji/tello"
 )
@@ -18,30 +23,90 @@ type Tello struct {
 	done                   chan interface{}
 	terminated             chan interface{}
 	closed                 bool
-	moves                  struct {
-		forward int
-		back    int
-		left    int
-		right   int
-		up      int
-		down    int
+	moves                  moves
+	logger                 *logging.Logger
+	internalCommands       chan dispatch
+	acknowledger           input.Acknowledger
+	exitID                 uint64
+	link                   *link
+	states                 chan ConnectionState
+	telemetry              telemetry
+	limits                 Limits
+	bus                    *event.Bus
+	bitrate                *bitrate
+	exposure               *exposure
+	recorder               Recorder
+	video                  bool
+	online                 int32
+	wide                   int32
+	cameraSeq              int32
+}
+
+// dispatch is a command read from the source along with its sequence number
+type dispatch struct {
+	id      uint64
+	command input.Command
+}
+
+// telloPublisher is the name the Tello robot publishes the events under
+const telloPublisher = "tello"
+
+// telloVideoPort is the local port the drone is asked to stream the video to
+const telloVideoPort = 6038
+
+// TelloAddress is where the Tello drone can be reached
+type TelloAddress struct {
+	// IP is the IP address of the drone
+	IP string
+	// CommandPort is the UDP port the drone listens on for the commands
+	CommandPort int
+	// LocalPort is the local UDP port the responses are received on
+	LocalPort int
+	// VideoPort is the local UDP port the video is streamed to
+	VideoPort int
+	// StatePort is the local UDP port the drone state is received on. Only the text SDK uses it.
+	StatePort int
+}
+
+// DefaultTelloAddress returns the address of a Tello drone in access point mode
+func DefaultTelloAddress() TelloAddress {
+	return TelloAddress{
+		IP:          "192.168.10.1",
+		CommandPort: 8889,
+		LocalPort:   8888,
+		VideoPort:   telloVideoPort,
+		StatePort:   8890,
 	}
-	verbosity        input.Verbosity
-	internalCommands chan input.Command
 }
 
 // NewTello creates a new Tello drone robot
-func NewTello(move, maxNumberOfMoves int, verbosity input.Verbosity) *Tello {
+func NewTello(move, maxNumberOfMoves int, logger *logging.Logger) *Tello {
+	t, _ := NewTelloAt(DefaultTelloAddress(), move, maxNumberOfMoves, logger)
+	return t
+}
+
+// NewTelloAt creates a new Tello drone robot which receives the drone responses on the local port of the address.
+// The underlying driver always talks to the drone on 192.168.10.1:8889 and receives the video on port 6038,
+// so an error is returned if the address asks for anything else.
+func NewTelloAt(address TelloAddress, move, maxNumberOfMoves int, logger *logging.Logger) (*Tello, error) {
+	def := DefaultTelloAddress()
+	if address.IP != def.IP || address.CommandPort != def.CommandPort || address.VideoPort != def.VideoPort {
+		return nil, fmt.Errorf("the tello robot can only talk to %s:%d and receive the video on port %d", def.IP, def.CommandPort, def.VideoPort)
+	}
 	return &Tello{
-		drone:            tello.NewDriver("8888"),
+		drone:            tello.NewDriver(strconv.Itoa(address.LocalPort)),
 		move:             move,
 		maxNumberOfMoves: maxNumberOfMoves,
 		errors:           make(chan error),
 		done:             make(chan interface{}),
 		terminated:       make(chan interface{}),
-		verbosity:        verbosity,
-		internalCommands: make(chan input.Command, 1000),
-	}
+		logger:           logger.With(logging.Fields{"robot": telloPublisher}),
+		internalCommands: make(chan dispatch, 1000),
+		link:             newLink(DefaultLinkPolicy()),
+		states:           make(chan ConnectionState, 10),
+		bitrate:          newBitrate(),
+		exposure:         newExposure(),
+	}, nil
 }
 
 // Errors returns any errors occurred during the execution of a command.
@@ -50,24 +115,125 @@ func (t *Tello) Errors() <-chan error {
 	return t.errors
 }
 
-// Video setup video feeds
+// ConnectionStates returns the changes in the health of the link between the host and the drone.
+// Reading from this channel is optional. The changes will be dropped if nobody is listening.
+func (t *Tello) ConnectionStates() <-chan ConnectionState {
+	return t.states
+}
+
+// SuperviseLink overrides the default link supervision policy.
+// It must be called before Connect.
+func (t *Tello) SuperviseLink(policy LinkPolicy) {
+	t.link = newLink(policy)
+}
+
+// State returns a snapshot of the drone state
+func (t *Tello) State() DroneState {
+	state := t.telemetry.snapshot()
+	state.Connection = t.link.current()
+	state.VideoBitRate = t.bitrate.current()
+	state.Exposure, state.AutoExposure = t.exposure.current()
+	state.WideVideo = atomic.LoadInt32(&t.wide) == 1
+	return state
+}
+
+// SetLimits sets the safety limits the robot enforces on top of the maximum number of moves.
+// It must be called before Connect.
+func (t *Tello) SetLimits(limits Limits) {
+	t.limits = limits
+}
+
+// SetEventBus sets the bus the robot publishes its lifecycle and flight events to.
+// It must be called before Connect.
+func (t *Tello) SetEventBus(bus *event.Bus) {
+	t.bus = bus
+}
+
+// SetVideoBitRate fixes the bit rate of the video and stops adapting it to the Wi-Fi conditions.
+// It can be called at any time. BitRateAuto hands the choice over to the drone.
+func (t *Tello) SetVideoBitRate(rate VideoBitRate) {
+	t.bitrate.set(rate)
+	t.logger.Infof("Video: Bit rate set to %s", rate)
+	if t.bitrate.streaming() {
+		t.applyBitRate(rate)
+	}
+}
+
+// AdaptVideoBitRate resumes adapting the bit rate of the video to the Wi-Fi conditions, which is the default
+func (t *Tello) AdaptVideoBitRate() {
+	t.bitrate.adapt()
+	t.logger.Infof("Video: Adaptive bit rate")
+	if t.bitrate.streaming() {
+		t.applyBitRate(t.bitrate.current())
+	}
+}
+
+// SetExposure fixes the exposure level of the camera and stops adapting it to the light. It can be called at any time.
+func (t *Tello) SetExposure(level Exposure) {
+	t.exposure.set(level)
+	t.logger.Infof("Camera: Exposure set to %s", level)
+	if t.isOnline() {
+		t.applyExposure(level)
+	}
+}
+
+// AutoExposure resumes adapting the exposure of the camera to the light, which is the default
+func (t *Tello) AutoExposure() {
+	t.exposure.adapt()
+	t.logger.Infof("Camera: Automatic exposure")
+}
+
+// ReportBrightness feeds the automatic exposure with the mean brightness (0-255) of a decoded frame.
+// The light strength reported by the drone is used while no frames are being decoded.
+func (t *Tello) ReportBrightness(brightness float64) {
+	t.exposure.frame(time.Now(), brightness)
+}
+
+// SetWideVideo switches between the normal (4:3) and the wide (16:9) video. The wide video is cropped from the normal picture.
+func (t *Tello) SetWideVideo(wide bool) error {
+	if !t.isOnline() {
+		return ErrDisconnected
+	}
+	seq := int16(atomic.AddInt32(&t.cameraSeq, 1))
+	if err := t.drone.SendCommand(string(videoModePacket(seq, wide))); err != nil {
+		return err
+	}
+	var mode int32
+	if wide {
+		mode = 1
+	}
+	atomic.StoreInt32(&t.wide, mode)
+	t.logger.Infof("Video: Wide video %v", wide)
+	t.bus.Publish(event.New(event.VideoMode, telloPublisher, wide))
+	return nil
+}
+
+// RequestKeyFrame asks the drone to send the SPS and PPS of the video, so that a player which has joined late gets a clean picture
+func (t *Tello) RequestKeyFrame() error {
+	if !t.isOnline() {
+		return ErrDisconnected
+	}
+	return t.drone.StartVideo()
+}
+
+// SetRecorder sets the recorder the ToggleRecording command pauses and resumes. The command is not supported if it's not set.
+// It must be called before Connect.
+func (t *Tello) SetRecorder(recorder Recorder) {
+	t.recorder = recorder
+}
+
+// Video setup video feeds.
+// The bit rate is stepped between 1 and 4 Mb/s according to the Wi-Fi signal and the stalls of the video, unless it has been fixed using SetVideoBitRate.
 // it need to be called before you connect to other source
 func (t *Tello) Video(output io.WriteCloser) error {
 	if nil == output {
 		return nil
 	}
+	t.video = true
+	// The tickers are started once by Connect, so the drone is only asked to stream the video again when it reconnects
 	_ = t.drone.On(tello.ConnectedEvent, func(data interface{}) {
-		_ = t.drone.SetVideoEncoderRate(tello.VideoBitRateAuto)
+		_ = t.drone.SetVideoEncoderRate(tello.VideoBitRate(t.bitrate.current()))
 		_ = t.drone.StartVideo()
-		// it need to send `StartVideo` to the drone every 100ms
-		gobot.Every(100*time.Millisecond, func() {
-			if t.closed {
-				return
-			}
-			if err := t.drone.StartVideo(); nil != err {
-				fmt.Printf("fail to start video on drone:%s\n", err)
-			}
-		})
 	})
 
 	_ = t.drone.On(tello.VideoFrameEvent, func(data interface{}) {
@@ -75,9 +241,10 @@ func (t *Tello) Video(output io.WriteCloser) error {
 			return
 		}
 		pkt := data.([]byte)
+		t.bitrate.packet(time.Now())
 		if len(pkt) > 0 {
 			if _, err := output.Write(pkt); err != nil {
-				fmt.Printf("err:%s\n", err)
+				t.logger.Errorf("fail to write the video feed: %s", err)
 			}
 		}
 	})
@@ -91,13 +258,44 @@ func (t *Tello) MonitorTermination() {
 // Connect establishes a new connection to the drone and blocks until the source's Commands channel is closed.
 func (t *Tello) Connect(source input.Source) error {
 	_ = t.drone.On(tello.FlightDataEvent, t.flightData)
+	_ = t.drone.On(tello.FlightDataEvent, t.heartbeat)
+	_ = t.drone.On(tello.WifiDataEvent, t.wifiData)
+	_ = t.drone.On(tello.LightStrengthEvent, t.lightStrength)
+	_ = t.drone.On(tello.ConnectedEvent, t.heartbeat)
+	_ = t.drone.On(tello.ConnectedEvent, t.connected)
+	t.forward(tello.TakeoffEvent, event.TakeOff)
+	t.forward(tello.LandingEvent, event.Landing)
+	t.forward(tello.PalmLandingEvent, event.PalmLanding)
+	t.forward(tello.FlipEvent, event.Flip)
+	t.forward(tello.BounceEvent, event.Bounce)
 
 	robot := gobot.NewRobot("tello",
 		[]gobot.Connection{},
 		[]gobot.Device{t.drone})
 
+	t.acknowledger, _ = source.(input.Acknowledger)
 	go t.filter(source.Commands())
 
+	t.link.start(time.Now())
+	stopLink := make(chan interface{})
+	linkWG := sync.WaitGroup{}
+	linkWG.Add(2)
+	go func() {
+		defer linkWG.Done()
+		t.superviseLink(stopLink, &linkWG)
+	}()
+	go func() {
+		defer linkWG.Done()
+		t.superviseExposure(stopLink)
+	}()
+	if t.video {
+		linkWG.Add(1)
+		go func() {
+			defer linkWG.Done()
+			t.superviseVideo(stopLink)
+		}()
+	}
+
 	wg := sync.WaitGroup{}
 	wg.Add(1)
 	go func() {
@@ -109,22 +307,46 @@ func (t *Tello) Connect(source input.Source) error {
 				t.printCommand(input.Exit)
 				t.closed = true
 				close(t.internalCommands)
+				for d := range t.internalCommands {
+					t.acknowledge(d, input.Superseded, nil)
+				}
+				t.acknowledge(dispatch{id: t.exitID, command: input.Exit}, input.Executed, nil)
 				close(t.done)
-			case cmd, more := <-t.internalCommands:
+			case d, more := <-t.internalCommands:
 				if !more {
 					t.closed = true
+					continue
+				}
+				cmd := d.command
+				if err := t.validate(cmd); err != nil {
+					t.errors <- err
+					t.acknowledge(d, input.Rejected, err)
+					continue
 				}
 				err, ignored := t.executeCommand(cmd)
 				if err != nil {
-					t.errors <- err
+					cmdErr, ok := err.(*CommandError)
+					if !ok {
+						cmdErr = t.commandError(cmd, PhaseSend, true, err)
+					}
+					t.errors <- cmdErr
+					if cmdErr.Phase == PhaseSend {
+						t.acknowledge(d, input.Failed, cmdErr)
+					} else {
+						t.acknowledge(d, input.Rejected, cmdErr)
+					}
 					continue
 				}
 
-				if !ignored {
-					t.printCommand(cmd)
+				if ignored {
+					t.acknowledge(d, input.Rejected, t.commandError(cmd, PhaseValidate, false, ErrUnsupported))
+					continue
 				}
 
-				if cmd.IsLandOrTakeoff() || ignored {
+				t.printCommand(cmd)
+				t.acknowledge(d, input.Executed, nil)
+
+				if cmd.IsLandOrTakeoff() || cmd.IsCamera() {
 					continue
 				}
 
@@ -139,8 +361,10 @@ func (t *Tello) Connect(source input.Source) error {
 
 		err := t.drone.Halt()
 		if err != nil {
-			t.errors <- err
+			t.errors <- t.commandError(input.Exit, PhaseSend, false, err)
 		}
+		close(stopLink)
+		linkWG.Wait()
 		close(t.errors)
 		return
 	}()
@@ -153,46 +377,380 @@ func (t *Tello) Connect(source input.Source) error {
 	return nil
 }
 
-func (t *Tello) printCommand(command input.Command) {
-	if t.verbosity >= input.Verbose {
-		fmt.Printf("Drone: %s Command Received\n", command)
+// acknowledge reports the outcome of a dispatched command back to the source, if the source is interested
+func (t *Tello) acknowledge(d dispatch, outcome input.Outcome, reason error) {
+	result := input.Result{
+		ID:      d.id,
+		Command: d.command,
+		Outcome: outcome,
+		Reason:  reason,
+	}
+	switch outcome {
+	case input.Executed:
+		t.bus.Publish(event.New(event.CommandExecuted, telloPublisher, result))
+	case input.Rejected:
+		t.bus.Publish(event.New(event.CommandRejected, telloPublisher, result))
+	case input.Failed:
+		t.bus.Publish(event.New(event.CommandFailed, telloPublisher, result))
 	}
+	if t.acknowledger == nil {
+		return
+	}
+	t.acknowledger.Acknowledge(result)
+}
+
+// forward republishes a drone event on the event bus
+func (t *Tello) forward(droneEvent string, eventType event.Type) {
+	_ = t.drone.On(droneEvent, func(interface{}) {
+		t.bus.Publish(event.New(eventType, telloPublisher, t.State()))
+	})
+}
+
+func (t *Tello) printCommand(command input.Command) {
+	t.logger.Log(logging.Info, logging.Fields{"command": command}, "Drone: %s Command Received", command)
 }
 
 func (t *Tello) flightData(s interface{}) {
-	if fd, ok := s.(*tello.FlightData); ok {
+	if fd, ok := s.(*tello.FlightData); ok && fd != nil {
+		previous := t.telemetry.update(fd)
+		if fd.BatteryLow && !previous.BatteryLow {
+			t.bus.Publish(event.New(event.BatteryLow, telloPublisher, t.State()))
+		}
+		landing := t.limits.LandBattery
+		if fd.EmSky && fd.BatteryPercentage < landing && (!previous.Reported || previous.Battery >= landing) {
+			t.logger.Warnf("Battery is below %d%%, landing", landing)
+			if err := t.drone.Land(); err != nil {
+				t.logger.Errorf("fail to land the drone: %s", err)
+			}
+		}
 		if fd.BatteryLow {
-			fmt.Printf("Battery is low %d%%\n", fd.BatteryPercentage)
+			t.logger.Warnf("Battery is low %d%%", fd.BatteryPercentage)
 			time.Sleep(5 * time.Second)
 		}
 	}
 }
 
+func (t *Tello) wifiData(s interface{}) {
+	if wd, ok := s.(*tello.WifiData); ok && wd != nil {
+		t.telemetry.setWifi(wd.Strength)
+		t.bitrate.wifi(wd.Strength, wd.Disturb)
+	}
+}
+
+// adaptBitRate steps the bit rate of the video up or down if the conditions have changed
+func (t *Tello) adaptBitRate() {
+	rate, changed, loss := t.bitrate.evaluate(time.Now())
+	if !changed {
+		return
+	}
+	t.logger.Log(logging.Info, logging.Fields{"loss": fmt.Sprintf("%.0f%%", loss*100), "wifi": t.telemetry.snapshot().WifiStrength}, "Video: Bit rate changed to %s", rate)
+	t.applyBitRate(rate)
+}
+
+// applyBitRate sends the bit rate to the drone and reports it
+func (t *Tello) applyBitRate(rate VideoBitRate) {
+	if err := t.drone.SetVideoEncoderRate(tello.VideoBitRate(rate)); err != nil {
+		t.logger.Errorf("fail to set the video bit rate: %s", err)
+		return
+	}
+	t.bus.Publish(event.New(event.VideoBitRate, telloPublisher, rate))
+}
+
+func (t *Tello) lightStrength(s interface{}) {
+	if strength, ok := s.(int8); ok {
+		t.exposure.light(strength)
+	}
+}
+
+// connected sends the exposure level to the drone, which forgets it when it reconnects
+func (t *Tello) connected(interface{}) {
+	atomic.StoreInt32(&t.online, 1)
+	level, _ := t.exposure.current()
+	if level != MinExposure {
+		t.applyExposure(level)
+	}
+}
+
+// superviseExposure adapts the exposure of the camera to the light until it's stopped
+func (t *Tello) superviseExposure(stop <-chan interface{}) {
+	ticker := time.NewTicker(exposureInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if !t.isOnline() {
+				continue
+			}
+			if level, changed := t.exposure.evaluate(now); changed {
+				t.logger.Infof("Camera: Exposure changed to %s", level)
+				t.applyExposure(level)
+			}
+		}
+	}
+}
+
+// superviseVideo keeps the video streaming and adapts its bit rate until it's stopped
+func (t *Tello) superviseVideo(stop <-chan interface{}) {
+	// it need to send `StartVideo` to the drone every 100ms
+	keepAlive := time.NewTicker(100 * time.Millisecond)
+	defer keepAlive.Stop()
+	adapt := time.NewTicker(bitrateInterval)
+	defer adapt.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-keepAlive.C:
+			if !t.isOnline() {
+				continue
+			}
+			if err := t.drone.StartVideo(); nil != err {
+				t.logger.Errorf("fail to start video on drone: %s", err)
+			}
+		case <-adapt.C:
+			if t.isOnline() {
+				t.adaptBitRate()
+			}
+		}
+	}
+}
+
+// isOnline returns true once the drone has responded, so that the settings can be sent to it. It returns false while the link is lost.
+func (t *Tello) isOnline() bool {
+	return atomic.LoadInt32(&t.online) == 1
+}
+
+// stepBitRate raises or lowers the bit rate of the video at the pilot's request
+func (t *Tello) stepBitRate(cmd input.Command, delta VideoBitRate) (error, bool) {
+	rate, changed := t.bitrate.step(delta)
+	if !changed {
+		return t.commandError(cmd, PhaseLimit, false, ErrOverLimit), true
+	}
+	t.logger.Infof("Video: Bit rate set to %s", rate)
+	if t.bitrate.streaming() {
+		t.applyBitRate(rate)
+	}
+	return nil, false
+}
+
+// toggleRecording pauses or resumes the recording of the video on the host
+func (t *Tello) toggleRecording() (error, bool) {
+	if t.recorder == nil {
+		return nil, true
+	}
+	recording := t.recorder.Toggle()
+	t.logger.Infof("Video: Recording %v", recording)
+	t.bus.Publish(event.New(event.Recording, telloPublisher, recording))
+	return nil, false
+}
+
+// stepExposure makes the picture brighter or darker at the pilot's request
+func (t *Tello) stepExposure(cmd input.Command, delta Exposure) (error, bool) {
+	level, changed := t.exposure.step(delta)
+	if !changed {
+		return t.commandError(cmd, PhaseLimit, false, ErrOverLimit), true
+	}
+	return t.applyExposure(level), false
+}
+
+// applyExposure sends the exposure level to the drone and reports it
+func (t *Tello) applyExposure(level Exposure) error {
+	if err := t.drone.SetExposure(int(level)); err != nil {
+		t.logger.Errorf("fail to set the exposure: %s", err)
+		return err
+	}
+	t.bus.Publish(event.New(event.Exposure, telloPublisher, level))
+	return nil
+}
+
+// heartbeat is called whenever the drone shows a sign of life
+func (t *Tello) heartbeat(s interface{}) {
+	t.link.beat(time.Now())
+}
+
+// validate checks whether the command can be executed in the current state of the drone
+func (t *Tello) validate(cmd input.Command) error {
+	if cmd == input.None {
+		return nil
+	}
+	if cmd != input.Land && t.link.current() == Lost {
+		return t.commandError(cmd, PhaseLink, true, ErrDisconnected)
+	}
+	state := t.telemetry.snapshot()
+	if !state.Reported {
+		if cmd == input.PalmLand {
+			// The height is unknown, so the drone may be too high to land on a palm
+			return t.commandError(cmd, PhaseLimit, true, ErrHeightUnknown)
+		}
+		return nil
+	}
+	switch cmd {
+	case input.TakeOff, input.ThrowTakeOff:
+		if cmd == input.ThrowTakeOff && state.Airborne {
+			// The drone would start waiting for a throw in mid air
+			return t.commandError(cmd, PhaseValidate, false, ErrAirborne)
+		}
+		if state.BatteryLow || state.Battery < t.limits.MinTakeOffBattery {
+			return t.commandError(cmd, PhaseValidate, false, ErrBatteryLow)
+		}
+	case input.PalmLand:
+		if !state.Airborne {
+			return t.commandError(cmd, PhaseValidate, true, ErrNotAirborne)
+		}
+		if state.Height > MaxPalmLandHeight {
+			return t.commandError(cmd, PhaseLimit, true, ErrTooHigh)
+		}
+	case input.Up:
+		if !state.Airborne {
+			return t.commandError(cmd, PhaseValidate, true, ErrNotAirborne)
+		}
+		if t.limits.MaxHeight > 0 && state.Height >= t.limits.MaxHeight {
+			return t.commandError(cmd, PhaseLimit, false, ErrOverLimit)
+		}
+	case input.Down, input.Forward, input.Backward, input.Left, input.Right, input.RotateLeft, input.RotateRight:
+		if !state.Airborne {
+			return t.commandError(cmd, PhaseValidate, true, ErrNotAirborne)
+		}
+	}
+	return nil
+}
+
+func (t *Tello) commandError(cmd input.Command, phase Phase, retryable bool, err error) *CommandError {
+	return &CommandError{
+		Command:   cmd,
+		Phase:     phase,
+		Retryable: retryable,
+		State:     t.State(),
+		Err:       err,
+	}
+}
+
+// superviseLink watches the drone heartbeat and tries to reconnect with an exponential backoff once the link is lost.
+func (t *Tello) superviseLink(stop <-chan interface{}, wg *sync.WaitGroup) {
+	ticker := time.NewTicker(linkCheckInterval)
+	defer ticker.Stop()
+	policy := t.link.policy
+	backoff := policy.MinBackoff
+	var retryAt time.Time
+	var failsafe bool
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			previous, current := t.link.evaluate(now)
+			if previous != current {
+				t.reportState(current, stop, wg)
+			}
+			if current != Lost {
+				if previous == Lost && failsafe && policy.Failsafe == FailsafeLand {
+					// Make sure the landing request gets through now that the drone can hear us again
+					_ = t.drone.Land()
+				}
+				failsafe = false
+				continue
+			}
+			if previous != Lost {
+				// The settings cannot reach the drone until it has reconnected, which marks it online again
+				atomic.StoreInt32(&t.online, 0)
+				failsafe = t.telemetry.snapshot().Airborne
+				if failsafe {
+					t.failsafe(policy.Failsafe)
+				}
+				backoff = policy.MinBackoff
+				retryAt = now.Add(backoff)
+				continue
+			}
+			if now.Before(retryAt) {
+				continue
+			}
+			if failsafe {
+				t.failsafe(policy.Failsafe)
+			}
+			t.reconnect()
+			backoff *= 2
+			if backoff > policy.MaxBackoff {
+				backoff = policy.MaxBackoff
+			}
+			retryAt = now.Add(backoff)
+		}
+	}
+}
+
+func (t *Tello) reportState(state ConnectionState, stop <-chan interface{}, wg *sync.WaitGroup) {
+	t.logger.Infof("Drone: Link %s", state)
+	select {
+	case t.states <- state:
+	default:
+	}
+	t.bus.Publish(event.New(event.ConnectionChanged, telloPublisher, state))
+	if state == Lost {
+		t.bus.Publish(event.New(event.Disconnected, telloPublisher, t.State()))
+		// The errors channel is not necessarily being read, so it must not hold up the reconnection attempts
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case t.errors <- t.commandError(input.None, PhaseLink, true, ErrDisconnected):
+			case <-stop:
+			}
+		}()
+	}
+}
+
+func (t *Tello) failsafe(action Failsafe) {
+	t.logger.Warnf("Drone: Failsafe %s", action)
+	t.bus.Publish(event.New(event.Failsafe, telloPublisher, action))
+	switch action {
+	case FailsafeLand:
+		_ = t.drone.Land()
+	default:
+		t.drone.Hover()
+		t.drone.CeaseRotation()
+	}
+}
+
+// reconnect repeats the connection handshake. The drone responds with a connected event as soon as it can hear us.
+func (t *Tello) reconnect() {
+	b := [2]byte{}
+	binary.LittleEndian.PutUint16(b[:], telloVideoPort)
+	err := t.drone.SendCommand(fmt.Sprintf("conn_req:%s", b))
+	if err != nil {
+		t.logger.Debugf("Drone: Reconnection failed: %s", err)
+	}
+}
+
 func (t *Tello) executeCommand(command input.Command) (error, bool) {
 	switch command {
 	case input.TakeOff:
 		return t.drone.TakeOff(), false
 	case input.Land:
 		return t.drone.Land(), false
+	case input.ThrowTakeOff:
+		return t.drone.ThrowTakeOff(), false
+	case input.PalmLand:
+		return t.drone.PalmLand(), false
 
 	case input.Left:
 		if t.isOverLimit(command) {
-			return nil, true
+			return t.commandError(command, PhaseLimit, false, ErrOverLimit), true
 		}
 		return t.drone.Left(t.move), false
 	case input.Right:
 		if t.isOverLimit(command) {
-			return nil, true
+			return t.commandError(command, PhaseLimit, false, ErrOverLimit), true
 		}
 		return t.drone.Right(t.move), false
 	case input.Forward:
 		if t.isOverLimit(command) {
-			return nil, true
+			return t.commandError(command, PhaseLimit, false, ErrOverLimit), true
 		}
 		return t.drone.Forward(t.move), false
 	case input.Backward:
 		if t.isOverLimit(command) {
-			return nil, true
+			return t.commandError(command, PhaseLimit, false, ErrOverLimit), true
 		}
 		return t.drone.Backward(t.move), false
 	case input.RotateRight:
@@ -204,90 +762,69 @@ func (t *Tello) executeCommand(command input.Command) (error, bool) {
 
 	case input.Up:
 		if t.isOverLimit(command) {
-			return nil, true
+			return t.commandError(command, PhaseLimit, false, ErrOverLimit), true
 		}
 		return t.drone.Up(t.move), false
 	case input.Down:
 		if t.isOverLimit(command) {
-			return nil, true
+			return t.commandError(command, PhaseLimit, false, ErrOverLimit), true
 		}
 		return t.drone.Down(t.move), false
 
 	default:
+		if command.IsCamera() {
+			return t.cameraCommand(command)
+		}
 		return nil, true
 	}
 }
 
-func (t *Tello) isOverLimit(cmd input.Command) bool {
-	if t.maxNumberOfMoves <= 0 {
-		return false
+// cameraCommand adjusts the video feed or the recording
+func (t *Tello) cameraCommand(command input.Command) (error, bool) {
+	switch command {
+	case input.ExposureUp:
+		return t.stepExposure(command, 1)
+	case input.ExposureDown:
+		return t.stepExposure(command, -1)
+	case input.AutoExposure:
+		t.AutoExposure()
+		return nil, false
+	case input.VideoNormal, input.VideoWide:
+		return t.SetWideVideo(command == input.VideoWide), false
+	case input.BitRateUp:
+		return t.stepBitRate(command, 1)
+	case input.BitRateDown:
+		return t.stepBitRate(command, -1)
+	case input.AdaptiveBitRate:
+		t.AdaptVideoBitRate()
+		return nil, false
+	case input.KeyFrame:
+		return t.RequestKeyFrame(), false
+	case input.ToggleRecording:
+		return t.toggleRecording()
+	default:
+		return nil, true
 	}
-	var current int
-	switch cmd {
-	case input.Left:
-		if t.moves.left < t.maxNumberOfMoves {
-			t.moves.left++
-		}
-		if t.moves.right > 0 {
-			t.moves.right--
-		}
-		current = t.moves.left
+}
 
-	case input.Right:
-		if t.moves.right < t.maxNumberOfMoves {
-			t.moves.right++
-		}
-		if t.moves.left > 0 {
-			t.moves.left--
-		}
-		current = t.moves.right
-	case input.Forward:
-		if t.moves.forward < t.maxNumberOfMoves {
-			t.moves.forward++
-		}
-		if t.moves.back > 0 {
-			t.moves.back--
-		}
-		current = t.moves.forward
-	case input.Backward:
-		if t.moves.back < t.maxNumberOfMoves {
-			t.moves.back++
-		}
-		if t.moves.forward > 0 {
-			t.moves.forward--
-		}
-		current = t.moves.back
-	case input.Up:
-		if t.moves.up < t.maxNumberOfMoves {
-			t.moves.up++
-		}
-		if t.moves.down > 0 {
-			t.moves.down--
-		}
-		current = t.moves.up
-	case input.Down:
-		if t.moves.down < t.maxNumberOfMoves {
-			t.moves.down++
-		}
-		if t.moves.up > 0 {
-			t.moves.up--
-		}
-		current = t.moves.down
-	default:
+func (t *Tello) isOverLimit(cmd input.Command) bool {
+	if t.maxNumberOfMoves <= 0 {
 		return false
 	}
-	if t.verbosity >= input.VeryVerbose {
-		fmt.Printf("Current Moves: %+v\n", t.moves)
-	}
-	return current >= t.maxNumberOfMoves
+	over := t.moves.register(cmd, t.maxNumberOfMoves)
+	t.logger.Debugf("Current Moves: %+v", t.moves)
+	return over
 }
 
 func (t *Tello) filter(commands <-chan input.Command) {
+	var id uint64
 	for cmd := range commands {
+		id++
 		if cmd == input.Exit {
+			t.exitID = id
 			close(t.terminated)
 			return
 		}
-		t.internalCommands <- cmd
+		t.internalCommands <- dispatch{id: id, command: cmd}
 	}
 }
@@ -0,0 +1,74 @@
+package robot
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xitonix/gophobotics/input"
+)
+
+func TestTelloGoesOfflineWhenTheLinkIsLost(t *testing.T) {
+	drone := NewTello(30, 0, newTestLogger())
+	drone.connected(nil)
+	if !drone.isOnline() {
+		t.Fatal("Expected the drone to be online once it has connected")
+	}
+	// The last heartbeat is older than the policy allows
+	drone.link.start(time.Now().Add(-time.Minute))
+
+	stop := make(chan interface{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		drone.superviseLink(stop, &wg)
+	}()
+	// The reconnection attempts only start after the minimum backoff
+	waitFor(t, "the drone to go offline", func() bool { return !drone.isOnline() })
+	close(stop)
+	wg.Wait()
+
+	if err := drone.SetWideVideo(true); err != ErrDisconnected {
+		t.Errorf("Expected the wide video to need a connection, got %v", err)
+	}
+	if err := drone.RequestKeyFrame(); err != ErrDisconnected {
+		t.Errorf("Expected the key frame to need a connection, got %v", err)
+	}
+	if state := drone.link.current(); state != Lost {
+		t.Errorf("Expected the link to be lost, got %s", state)
+	}
+}
+
+func TestTelloValidatesThrowAndPalm(t *testing.T) {
+	testCases := []struct {
+		title    string
+		state    DroneState
+		cmd      input.Command
+		expected error
+	}{
+		{title: "throw on the ground", state: DroneState{Reported: true, Battery: 80}, cmd: input.ThrowTakeOff},
+		{title: "throw in the air", state: DroneState{Reported: true, Airborne: true, Battery: 80}, cmd: input.ThrowTakeOff, expected: ErrAirborne},
+		{title: "throw with a low battery", state: DroneState{Reported: true, Battery: 5}, cmd: input.ThrowTakeOff, expected: ErrBatteryLow},
+		{title: "palm landing low enough", state: DroneState{Reported: true, Airborne: true, Height: 10}, cmd: input.PalmLand},
+		{title: "palm landing too high", state: DroneState{Reported: true, Airborne: true, Height: 20}, cmd: input.PalmLand, expected: ErrTooHigh},
+		{title: "palm landing on the ground", state: DroneState{Reported: true}, cmd: input.PalmLand, expected: ErrNotAirborne},
+		{title: "palm landing at an unknown height", cmd: input.PalmLand, expected: ErrHeightUnknown},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			drone := NewTello(30, 0, newTestLogger())
+			drone.SetLimits(Limits{MinTakeOffBattery: 20})
+			drone.link.start(time.Now())
+			drone.telemetry.set(tc.state)
+			err := drone.validate(tc.cmd)
+			if tc.expected == nil && err != nil {
+				t.Errorf("Expected %s to be valid, got %v", tc.cmd, err)
+			}
+			if tc.expected != nil && !errors.Is(err, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
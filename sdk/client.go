@@ -0,0 +1,340 @@
+// Package sdk implements the official Tello SDK text protocol.
+//
+// The commands (ie. "takeoff", "forward 50" or "battery?") are sent as plain text to the command port of the drone,
+// which replies to each of them with "ok", "error ..." or the requested value. The drone also broadcasts its state
+// to the state port of the host several times a second once it has entered the SDK mode.
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCommandPort is the UDP port the drone listens on for the commands
+	DefaultCommandPort = 8889
+	// DefaultStatePort is the local UDP port the drone broadcasts its state to
+	DefaultStatePort = 8890
+	// DefaultTimeout is how long the client waits for the response of a command
+	DefaultTimeout = 7 * time.Second
+)
+
+var (
+	// ErrTimeout is returned when the drone does not respond to a command in time
+	ErrTimeout = errors.New("the drone did not respond in time")
+	// ErrClosed is returned when a command is sent after the client has been closed
+	ErrClosed = errors.New("the client has been closed")
+	// ErrBusy is returned when the drone has not responded to a previous command, which has timed out, in time for the next one
+	ErrBusy = errors.New("the drone has not responded to the previous command yet")
+)
+
+// RejectedError is returned when the drone responds to a command with an error
+type RejectedError struct {
+	// Command is the command which has been rejected
+	Command string
+	// Response is the response of the drone (ie. "error Not joystick")
+	Response string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("%q has been rejected by the drone: %s", e.Command, e.Response)
+}
+
+// Address is where the drone and the host exchange the messages
+type Address struct {
+	// IP is the IP address of the drone
+	IP string
+	// CommandPort is the UDP port the drone listens on for the commands
+	CommandPort int
+	// LocalPort is the local UDP port the responses are received on. Zero picks a random port.
+	LocalPort int
+	// StatePort is the local UDP port the state is received on. Zero disables the state.
+	StatePort int
+}
+
+// Client talks to a drone using the SDK text protocol. The drone only handles one command at a time,
+// so the commands are sent one after another, each waiting for its response before the next one goes out.
+//
+// The responses do not say which command they belong to. A command which has timed out may still be answered, so the next command
+// is only sent once that response has arrived, or once the command has been given as long again and its response is considered lost.
+// On top of that, Do ignores the responses which are not an acknowledgement (ie. a battery level) and Read ignores the "ok" responses,
+// so a response which arrives even later cannot be taken for the response of a different kind of command.
+type Client struct {
+	conn      *net.UDPConn
+	state     *net.UDPConn
+	responses chan string
+	done      chan interface{}
+	wg        sync.WaitGroup
+
+	// sendMux makes sure only one command is in flight
+	sendMux sync.Mutex
+	// unanswered is the number of commands which have timed out and whose responses may still arrive
+	unanswered int
+	// lostAt is when the responses of the unanswered commands are considered lost
+	lostAt time.Time
+
+	stateMux  sync.Mutex
+	lastState State
+	stateAt   time.Time
+	onState   func(State)
+
+	closeMux sync.Mutex
+	closed   bool
+}
+
+// Dial opens the connections to the drone and enters the SDK mode by sending the "command" command
+func Dial(address Address, timeout time.Duration) (*Client, error) {
+	c, err := Open(address)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Do("command", timeout); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Open opens the connections to the drone without entering the SDK mode
+func Open(address Address) (*Client, error) {
+	remote, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", address.IP, address.CommandPort))
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp", &net.UDPAddr{Port: address.LocalPort}, remote)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		conn:      conn,
+		responses: make(chan string, 10),
+		done:      make(chan interface{}),
+	}
+	if address.StatePort > 0 {
+		state, err := net.ListenUDP("udp", &net.UDPAddr{Port: address.StatePort})
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		c.state = state
+		c.wg.Add(1)
+		go c.receiveState()
+	}
+	c.wg.Add(1)
+	go c.receiveResponses()
+	return c, nil
+}
+
+// OnState registers a function which is called with every state the drone broadcasts.
+// Register it between Open and the "command" command to receive the states from the start, since the drone only broadcasts them in the SDK mode.
+func (c *Client) OnState(handler func(State)) {
+	c.stateMux.Lock()
+	defer c.stateMux.Unlock()
+	c.onState = handler
+}
+
+// State returns the latest state received from the drone and when it arrived.
+// The time is zero if no state has been received yet.
+func (c *Client) State() (State, time.Time) {
+	c.stateMux.Lock()
+	defer c.stateMux.Unlock()
+	return c.lastState, c.stateAt
+}
+
+// Send sends a command and returns the response of the drone. A zero timeout waits for DefaultTimeout.
+// If the drone has not responded to a previous command which has timed out, Send waits for that response first and returns ErrBusy
+// if it does not arrive in time.
+func (c *Client) Send(command string, timeout time.Duration) (string, error) {
+	return c.send(command, timeout, false, anyResponse)
+}
+
+// Interrupt sends a command straight away, even if the drone has not responded to a previous command (ie. to land in an emergency).
+// The response of the previous command is not waited for, so it could be taken for the response of this command.
+func (c *Client) Interrupt(command string, timeout time.Duration) (string, error) {
+	return c.send(command, timeout, true, anyResponse)
+}
+
+// send sends the command and waits for a response which the accept function considers to belong to it
+func (c *Client) send(command string, timeout time.Duration, interrupt bool, accept func(response string) bool) (string, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	c.sendMux.Lock()
+	defer c.sendMux.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	if interrupt {
+		c.unanswered = 0
+	}
+	if err := c.awaitUnanswered(timer.C); err != nil {
+		return "", err
+	}
+
+	// Drop the responses nobody has asked for
+	for {
+		select {
+		case <-c.responses:
+			continue
+		default:
+		}
+		break
+	}
+
+	if _, err := c.conn.Write([]byte(command)); err != nil {
+		select {
+		case <-c.done:
+			return "", ErrClosed
+		default:
+			return "", err
+		}
+	}
+	for {
+		select {
+		case response := <-c.responses:
+			if !accept(response) {
+				// The late response of another command
+				continue
+			}
+			return response, nil
+		case <-timer.C:
+			if c.unanswered == 0 {
+				c.lostAt = time.Now().Add(timeout)
+			}
+			c.unanswered++
+			return "", ErrTimeout
+		case <-c.done:
+			return "", ErrClosed
+		}
+	}
+}
+
+// awaitUnanswered waits for the responses of the commands which have timed out, until they are considered lost.
+// It returns ErrBusy if the timeout expires first.
+func (c *Client) awaitUnanswered(timeout <-chan time.Time) error {
+	if c.unanswered == 0 {
+		return nil
+	}
+	lost := time.NewTimer(time.Until(c.lostAt))
+	defer lost.Stop()
+	for c.unanswered > 0 {
+		select {
+		case <-c.responses:
+			c.unanswered--
+		case <-lost.C:
+			c.unanswered = 0
+		case <-timeout:
+			return ErrBusy
+		case <-c.done:
+			return ErrClosed
+		}
+	}
+	return nil
+}
+
+// Do sends a command and expects the drone to respond with "ok"
+func (c *Client) Do(command string, timeout time.Duration) error {
+	response, err := c.send(command, timeout, false, isAcknowledgement)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(response, "ok") {
+		return &RejectedError{Command: command, Response: response}
+	}
+	return nil
+}
+
+// Read sends a read command (ie. "battery?") and returns the value the drone responds with
+func (c *Client) Read(command string, timeout time.Duration) (string, error) {
+	response, err := c.send(command, timeout, false, isValue)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(response, "error") {
+		return "", &RejectedError{Command: command, Response: response}
+	}
+	return response, nil
+}
+
+func anyResponse(string) bool {
+	return true
+}
+
+// isAcknowledgement returns true for the responses of the control commands
+func isAcknowledgement(response string) bool {
+	return strings.EqualFold(response, "ok") || strings.HasPrefix(response, "error")
+}
+
+// isValue returns true for the responses of the read commands, which are never "ok"
+func isValue(response string) bool {
+	return !strings.EqualFold(response, "ok")
+}
+
+// Close closes the connections to the drone
+func (c *Client) Close() error {
+	c.closeMux.Lock()
+	if c.closed {
+		c.closeMux.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.closeMux.Unlock()
+
+	close(c.done)
+	err := c.conn.Close()
+	if c.state != nil {
+		_ = c.state.Close()
+	}
+	c.wg.Wait()
+	return err
+}
+
+func (c *Client) receiveResponses() {
+	defer c.wg.Done()
+	buf := make([]byte, 1024)
+	for {
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			select {
+			case <-c.done:
+				return
+			default:
+			}
+			// A refused connection is reported on the next read when nobody listens on the drone side.
+			// The command will time out, so there is nothing else to do.
+			continue
+		}
+		select {
+		case c.responses <- strings.TrimSpace(string(buf[:n])):
+		default:
+			// Nobody is waiting for this response
+		}
+	}
+}
+
+func (c *Client) receiveState() {
+	defer c.wg.Done()
+	buf := make([]byte, 1024)
+	for {
+		n, _, err := c.state.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		s, err := ParseState(string(buf[:n]))
+		if err != nil {
+			continue
+		}
+		c.stateMux.Lock()
+		c.lastState = s
+		c.stateAt = time.Now()
+		handler := c.onState
+		c.stateMux.Unlock()
+		if handler != nil {
+			handler(s)
+		}
+	}
+}
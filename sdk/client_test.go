@@ -0,0 +1,164 @@
+package sdk_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xitonix/gophobotics/fake"
+	"github.com/xitonix/gophobotics/sdk"
+)
+
+func dial(t *testing.T) (*sdk.Client, *fake.SDK) {
+	t.Helper()
+	drone, err := fake.NewSDK("127.0.0.1:0", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = drone.Close() })
+	client, err := sdk.Dial(sdk.Address{IP: "127.0.0.1", CommandPort: drone.Port()}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client, drone
+}
+
+func TestClientCommands(t *testing.T) {
+	client, drone := dial(t)
+
+	battery, err := client.Read("battery?", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if battery != "87" {
+		t.Errorf("Expected the battery to be 87, got %q", battery)
+	}
+	for _, command := range []string{"takeoff", "forward 50", "cw 90", "land"} {
+		if err := client.Do(command, time.Second); err != nil {
+			t.Fatalf("%s failed: %s", command, err)
+		}
+	}
+	expected := []string{"command", "battery?", "takeoff", "forward 50", "cw 90", "land"}
+	if received := drone.Commands(); len(received) != len(expected) {
+		t.Errorf("Expected the drone to receive %v, got %v", expected, received)
+	}
+}
+
+func TestClientRejectedCommand(t *testing.T) {
+	client, _ := dial(t)
+
+	err := client.Do("forward 50", time.Second)
+	var rejected *sdk.RejectedError
+	if !errors.As(err, &rejected) {
+		t.Fatalf("Expected the command to be rejected, got %v", err)
+	}
+	if rejected.Response != "error Not airborne" {
+		t.Errorf("Expected the response of the drone, got %q", rejected.Response)
+	}
+	if _, err := client.Read("bogus?", time.Second); !errors.As(err, &rejected) {
+		t.Errorf("Expected the read to be rejected, got %v", err)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	client, drone := dial(t)
+	drone.SetSilent(true)
+
+	if err := client.Do("takeoff", 100*time.Millisecond); err != sdk.ErrTimeout {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+}
+
+func TestClientWaitsForLateResponses(t *testing.T) {
+	client, drone := dial(t)
+	// The response to the take off arrives after it has timed out, while the next command would be waiting
+	// for its own response if it was sent straight away
+	drone.SetDelay(300 * time.Millisecond)
+
+	if err := client.Do("forward 50", 200*time.Millisecond); err != sdk.ErrTimeout {
+		t.Fatalf("Expected a timeout, got %v", err)
+	}
+	if err := client.Do("takeoff", 2*time.Second); err != nil {
+		t.Fatalf("Expected the take off to succeed, got %v", err)
+	}
+}
+
+func TestClientIgnoresResponsesOfAnotherKind(t *testing.T) {
+	client, drone := dial(t)
+	// The response to the take off only arrives once it's considered lost and the next command has been sent
+	drone.SetDelay(300 * time.Millisecond)
+
+	if err := client.Do("takeoff", 100*time.Millisecond); err != sdk.ErrTimeout {
+		t.Fatalf("Expected a timeout, got %v", err)
+	}
+	battery, err := client.Read("battery?", 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if battery != "87" {
+		t.Errorf("Expected the battery, got the late response %q", battery)
+	}
+	if err := client.Do("forward 50", 2*time.Second); err != nil {
+		t.Errorf("Expected the late battery response not to be taken for the response of the move, got %v", err)
+	}
+}
+
+func TestClientBusyUntilTheResponseIsLost(t *testing.T) {
+	client, drone := dial(t)
+	drone.SetSilent(true)
+
+	if err := client.Do("takeoff", 200*time.Millisecond); err != sdk.ErrTimeout {
+		t.Fatalf("Expected a timeout, got %v", err)
+	}
+	drone.SetSilent(false)
+
+	// The response of the take off may still arrive, so the next command is held back
+	sent := len(drone.Commands())
+	if _, err := client.Read("battery?", 50*time.Millisecond); err != sdk.ErrBusy {
+		t.Fatalf("Expected the drone to be busy, got %v", err)
+	}
+	if len(drone.Commands()) != sent {
+		t.Error("Expected the command not to be sent while the drone is busy")
+	}
+
+	// The response of the take off is considered lost once it has been given as long again
+	battery, err := client.Read("battery?", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if battery != "87" {
+		t.Errorf("Expected the battery, got %q", battery)
+	}
+}
+
+func TestClientInterrupt(t *testing.T) {
+	client, drone := dial(t)
+	drone.SetSilent(true)
+
+	if err := client.Do("takeoff", 100*time.Millisecond); err != sdk.ErrTimeout {
+		t.Fatalf("Expected a timeout, got %v", err)
+	}
+	drone.SetSilent(false)
+
+	start := time.Now()
+	response, err := client.Interrupt("emergency", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response != "ok" {
+		t.Errorf("Expected ok, got %q", response)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected the command to be sent straight away, it took %s", elapsed)
+	}
+}
+
+func TestClientClosed(t *testing.T) {
+	client, _ := dial(t)
+	_ = client.Close()
+
+	if err := client.Do("takeoff", time.Second); err != sdk.ErrClosed {
+		t.Errorf("Expected the client to be closed, got %v", err)
+	}
+}
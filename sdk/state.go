@@ -0,0 +1,118 @@
+package sdk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// State is the status the drone broadcasts on the state port several times a second
+type State struct {
+	// Pitch, Roll and Yaw are the attitude of the drone in degrees
+	Pitch, Roll, Yaw int
+	// SpeedX, SpeedY and SpeedZ are the speeds of the drone in dm/s
+	SpeedX, SpeedY, SpeedZ int
+	// TempLow and TempHigh are the lowest and the highest temperatures in °C
+	TempLow, TempHigh int
+	// TOF is the distance from the ground measured by the time of flight sensor in cm
+	TOF int
+	// Height is the height of the drone in cm
+	Height int
+	// Battery is the remaining battery percentage
+	Battery int
+	// Barometer is the barometer measurement in cm
+	Barometer float64
+	// MotorTime is the amount of time the motors have been running in seconds
+	MotorTime int
+	// AccelerationX, AccelerationY and AccelerationZ are the accelerations in 0.001g
+	AccelerationX, AccelerationY, AccelerationZ float64
+	// MissionPad is the ID of the mission pad the drone has detected (Tello EDU only).
+	// It is NoPad if no pad has been detected and PadsDisabled if the detection is off or the drone does not support it.
+	MissionPad int
+	// PadX, PadY and PadZ are the position of the drone relative to the detected mission pad in cm.
+	// X points to the front of the pad, Y to the left and Z up.
+	PadX, PadY, PadZ int
+	// PadYaw is the heading of the drone relative to the detected mission pad in degrees
+	PadYaw int
+	// Values are all the raw values of the state by key, including the ones which are not parsed
+	Values map[string]string
+}
+
+const (
+	// NoPad means the mission pad detection is on, but the drone is not flying over a pad
+	NoPad = -1
+	// PadsDisabled means the mission pad detection is off
+	PadsDisabled = -2
+)
+
+// PadDetected returns true if the drone is flying over a mission pad
+func (s State) PadDetected() bool {
+	return s.MissionPad > 0
+}
+
+// ParseState parses the state string of the drone (ie. "pitch:0;roll:0;yaw:0;...;bat:87;...\r\n")
+func ParseState(raw string) (State, error) {
+	s := State{Values: make(map[string]string), MissionPad: PadsDisabled}
+	for _, pair := range strings.Split(strings.TrimSpace(raw), ";") {
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, ":", 2)
+		if len(kv) != 2 {
+			return s, fmt.Errorf("invalid state value %q", pair)
+		}
+		s.Values[kv[0]] = kv[1]
+	}
+	if len(s.Values) == 0 {
+		return s, fmt.Errorf("empty state")
+	}
+
+	ints := map[string]*int{
+		"pitch": &s.Pitch, "roll": &s.Roll, "yaw": &s.Yaw,
+		"vgx": &s.SpeedX, "vgy": &s.SpeedY, "vgz": &s.SpeedZ,
+		"templ": &s.TempLow, "temph": &s.TempHigh,
+		"tof": &s.TOF, "h": &s.Height, "bat": &s.Battery, "time": &s.MotorTime,
+		"mid": &s.MissionPad, "x": &s.PadX, "y": &s.PadY, "z": &s.PadZ,
+	}
+	for key, target := range ints {
+		value, ok := s.Values[key]
+		if !ok {
+			continue
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return s, fmt.Errorf("invalid %s value %q", key, value)
+		}
+		*target = v
+	}
+
+	// mpry is the pitch, the roll and the yaw of the drone relative to the mission pad
+	if value, ok := s.Values["mpry"]; ok {
+		angles := strings.Split(value, ",")
+		if len(angles) != 3 {
+			return s, fmt.Errorf("invalid mpry value %q", value)
+		}
+		yaw, err := strconv.Atoi(angles[2])
+		if err != nil {
+			return s, fmt.Errorf("invalid mpry value %q", value)
+		}
+		s.PadYaw = yaw
+	}
+
+	floats := map[string]*float64{
+		"baro": &s.Barometer,
+		"agx":  &s.AccelerationX, "agy": &s.AccelerationY, "agz": &s.AccelerationZ,
+	}
+	for key, target := range floats {
+		value, ok := s.Values[key]
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return s, fmt.Errorf("invalid %s value %q", key, value)
+		}
+		*target = v
+	}
+	return s, nil
+}
@@ -0,0 +1,58 @@
+package viewer
+
+// page shows the live picture and polls the telemetry every half a second
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Gophobotics</title>
+<style>
+  body { margin: 0; background: #111; color: #eee; font-family: sans-serif; display: flex; flex-wrap: wrap; }
+  img { flex: 1 1 640px; max-width: 100%; background: #000; }
+  table { margin: 1em; border-collapse: collapse; align-self: flex-start; }
+  td { padding: 0.3em 1em; font-size: 1.3em; }
+  td:first-child { color: #999; }
+  .warning { color: #ff5a3c; }
+</style>
+</head>
+<body>
+<img src="/stream" alt="Waiting for the video feed">
+<table>
+  <tr><td>Link</td><td id="link">-</td></tr>
+  <tr><td>Flying</td><td id="airborne">-</td></tr>
+  <tr><td>Height</td><td id="height">-</td></tr>
+  <tr><td>Speed</td><td id="speed">-</td></tr>
+  <tr><td>Battery</td><td id="battery">-</td></tr>
+  <tr><td>Wi-Fi</td><td id="wifi">-</td></tr>
+  <tr><td>Bit rate</td><td id="bitrate">-</td></tr>
+  <tr><td>Exposure</td><td id="exposure">-</td></tr>
+</table>
+<script>
+function show(id, text, warning) {
+  var cell = document.getElementById(id);
+  cell.textContent = text;
+  cell.className = warning ? "warning" : "";
+}
+function refresh() {
+  fetch("/telemetry").then(function (r) { return r.json(); }).then(function (t) {
+    show("link", t.link || "-", t.link === "Lost");
+    if (!t.reported) {
+      return;
+    }
+    show("airborne", t.airborne ? "Yes" : "No");
+    show("height", (t.height / 10).toFixed(1) + " m");
+    show("speed", (t.speed / 10).toFixed(1) + " m/s");
+    show("battery", t.battery + "%", t.battery < 20);
+    show("wifi", t.wifi > 0 ? t.wifi + "%" : "-", t.wifi > 0 && t.wifi < 40);
+    show("bitrate", t.bit_rate);
+    show("exposure", t.exposure);
+  }).catch(function () {
+    show("link", "Viewer stopped", true);
+  });
+}
+setInterval(refresh, 500);
+refresh();
+</script>
+</body>
+</html>
+`
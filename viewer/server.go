@@ -0,0 +1,218 @@
+// Package viewer serves the video feed of the drone to a web browser, so no video player needs to be installed.
+//
+// The decoded frames are compressed into JPEG pictures and streamed as MJPEG, which every browser can display in an img tag.
+// The page shows the live picture alongside the telemetry of the drone, which the page polls as JSON.
+package viewer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/jpeg"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/xitonix/gophobotics/logging"
+	"github.com/xitonix/gophobotics/vision"
+)
+
+const (
+	// DefaultAddress is the address the viewer listens on by default
+	DefaultAddress = "localhost:8080"
+	// jpegQuality is the quality of the streamed pictures (1-100)
+	jpegQuality = 75
+	// boundary separates the pictures of the MJPEG stream
+	boundary = "gophoboticsframe"
+)
+
+// Telemetry is the state of the drone shown next to the picture
+type Telemetry struct {
+	// Reported is false until the drone has sent its first flight data
+	Reported bool `json:"reported"`
+	Airborne bool `json:"airborne"`
+	// Height is in decimetres
+	Height int `json:"height"`
+	// Speed is in decimetres per second
+	Speed   int `json:"speed"`
+	Battery int `json:"battery"`
+	Wifi    int `json:"wifi"`
+	// BitRate is the bit rate of the video
+	BitRate string `json:"bit_rate"`
+	// Exposure is the exposure level of the camera
+	Exposure string `json:"exposure"`
+	// Link is the health of the link to the drone
+	Link string `json:"link"`
+}
+
+// Server streams the frames to the browsers. Each viewer gets the latest picture, so a slow browser skips frames instead of lagging behind.
+type Server struct {
+	address   string
+	frames    <-chan vision.Frame
+	telemetry func() Telemetry
+	logger    *logging.Logger
+	server    *http.Server
+	listener  net.Listener
+
+	mux     sync.Mutex
+	picture []byte
+	seq     uint64
+	updated chan interface{}
+	done    bool
+}
+
+// New creates a new viewer which serves the frames on the specified address (ie. localhost:8080)
+func New(address string, frames <-chan vision.Frame, logger *logging.Logger) *Server {
+	s := &Server{
+		address: address,
+		frames:  frames,
+		logger:  logger.With(logging.Fields{"component": "viewer"}),
+		updated: make(chan interface{}),
+	}
+	handler := http.NewServeMux()
+	handler.HandleFunc("/", s.page)
+	handler.HandleFunc("/stream", s.stream)
+	handler.HandleFunc("/telemetry", s.state)
+	s.server = &http.Server{Handler: handler}
+	return s
+}
+
+// SetTelemetry sets the function the telemetry shown on the page is read from. No telemetry is shown if it's not set.
+// It must be called before Start.
+func (s *Server) SetTelemetry(telemetry func() Telemetry) {
+	s.telemetry = telemetry
+}
+
+// Listen starts listening on the address of the viewer, so that the URL is known before the frames are served
+func (s *Server) Listen() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return fmt.Errorf("failed to start the viewer: %s", err)
+	}
+	s.listener = listener
+	return nil
+}
+
+// URL returns the address of the page. It must be called after Listen.
+func (s *Server) URL() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+// Start serves the frames and blocks until the viewer has been closed
+func (s *Server) Start() error {
+	if s.listener == nil {
+		if err := s.Listen(); err != nil {
+			return err
+		}
+	}
+	go s.compress()
+	if err := s.server.Serve(s.listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Close disconnects the browsers and stops the viewer
+func (s *Server) Close() error {
+	s.mux.Lock()
+	if !s.done {
+		s.done = true
+		close(s.updated)
+	}
+	s.mux.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+// compress turns the frames into JPEG pictures and wakes up the streams
+func (s *Server) compress() {
+	var buf bytes.Buffer
+	for frame := range s.frames {
+		buf.Reset()
+		if err := jpeg.Encode(&buf, frame.Image, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			s.logger.Errorf("Viewer: Failed to compress the frame: %s", err)
+			continue
+		}
+		s.mux.Lock()
+		if s.done {
+			s.mux.Unlock()
+			return
+		}
+		s.picture = append([]byte(nil), buf.Bytes()...)
+		s.seq++
+		close(s.updated)
+		s.updated = make(chan interface{})
+		s.mux.Unlock()
+	}
+}
+
+// next waits for a picture newer than the previous one. It returns false once the viewer has been closed or the browser has gone.
+func (s *Server) next(previous uint64, cancelled <-chan struct{}) ([]byte, uint64, bool) {
+	for {
+		s.mux.Lock()
+		picture, seq, updated, done := s.picture, s.seq, s.updated, s.done
+		s.mux.Unlock()
+		if done {
+			return nil, 0, false
+		}
+		if seq > previous {
+			return picture, seq, true
+		}
+		select {
+		case <-updated:
+		case <-cancelled:
+			return nil, 0, false
+		}
+	}
+}
+
+func (s *Server) stream(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+boundary)
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+	s.logger.Infof("Viewer: %s connected", r.RemoteAddr)
+	defer s.logger.Infof("Viewer: %s disconnected", r.RemoteAddr)
+
+	var seq uint64
+	for {
+		picture, next, ok := s.next(seq, r.Context().Done())
+		if !ok {
+			return
+		}
+		seq = next
+		if _, err := fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", boundary, len(picture)); err != nil {
+			return
+		}
+		if _, err := w.Write(picture); err != nil {
+			return
+		}
+		if _, err := w.Write([]byte("\r\n")); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) state(w http.ResponseWriter, r *http.Request) {
+	var telemetry Telemetry
+	if s.telemetry != nil {
+		telemetry = s.telemetry()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	_ = json.NewEncoder(w).Encode(telemetry)
+}
+
+func (s *Server) page(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(page))
+}
@@ -0,0 +1,26 @@
+package viewer
+
+import "github.com/xitonix/gophobotics/robot"
+
+// DroneTelemetry converts the state reported by the robots into the telemetry shown on the page
+func DroneTelemetry(state robot.DroneState) Telemetry {
+	return Telemetry{
+		Reported: state.Reported,
+		Airborne: state.Airborne,
+		Height:   int(state.Height),
+		Speed:    int(state.Speed),
+		Battery:  int(state.Battery),
+		Wifi:     int(state.WifiStrength),
+		BitRate:  state.VideoBitRate.String(),
+		Exposure: exposure(state),
+		Link:     state.Connection.String(),
+	}
+}
+
+// exposure describes the exposure level of the camera (ie. 1 (auto))
+func exposure(state robot.DroneState) string {
+	if state.AutoExposure {
+		return state.Exposure.String() + " (auto)"
+	}
+	return state.Exposure.String()
+}
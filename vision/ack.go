@@ -0,0 +1,45 @@
+package vision
+
+import "time"
+
+// ackTimeout is how long the vision sources wait for a command to be acknowledged before sending the next one
+const ackTimeout = 10 * time.Second
+
+// ackGate keeps a single command in flight: the next command can only be sent once the last one has been acknowledged
+// or has timed out, since not all the robots acknowledge the commands. It's not thread safe, so the owner must guard it.
+type ackGate struct {
+	pending bool
+	sent    time.Time
+}
+
+// send records a command sent at the specified time
+func (g *ackGate) send(at time.Time) {
+	g.pending = true
+	g.sent = at
+}
+
+// acknowledge opens the gate and returns false if no command was waiting for its acknowledgement
+func (g *ackGate) acknowledge() bool {
+	pending := g.pending
+	g.pending = false
+	return pending
+}
+
+// waiting returns true if the last command has neither been acknowledged nor timed out
+func (g *ackGate) waiting(now time.Time) bool {
+	return g.pending && now.Sub(g.sent) < ackTimeout
+}
+
+// expire opens the gate and returns true if the last command has timed out without being acknowledged
+func (g *ackGate) expire(now time.Time) bool {
+	if !g.pending || g.waiting(now) {
+		return false
+	}
+	g.pending = false
+	return true
+}
+
+// since returns how long ago the last command was sent
+func (g *ackGate) since(now time.Time) time.Duration {
+	return now.Sub(g.sent)
+}
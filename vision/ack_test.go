@@ -0,0 +1,39 @@
+package vision
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAckGate(t *testing.T) {
+	start := time.Now()
+	var gate ackGate
+	if gate.waiting(start) || gate.expire(start) || gate.acknowledge() {
+		t.Fatal("Expected a new gate to be open")
+	}
+
+	gate.send(start)
+	if !gate.waiting(start.Add(ackTimeout - time.Millisecond)) {
+		t.Error("Expected the gate to wait for the acknowledgement")
+	}
+	if gate.expire(start.Add(ackTimeout - time.Millisecond)) {
+		t.Error("Expected the command not to time out before the timeout")
+	}
+	if !gate.acknowledge() || gate.waiting(start) {
+		t.Error("Expected the acknowledgement to open the gate")
+	}
+
+	gate.send(start)
+	if gate.waiting(start.Add(ackTimeout)) {
+		t.Error("Expected the gate to stop waiting after the timeout")
+	}
+	if !gate.expire(start.Add(ackTimeout)) {
+		t.Error("Expected the command to time out")
+	}
+	if gate.acknowledge() {
+		t.Error("Expected a late acknowledgement to find the gate open")
+	}
+	if since := gate.since(start.Add(time.Second)); since != time.Second {
+		t.Errorf("Expected the last command to be sent a second ago, got %s", since)
+	}
+}
@@ -0,0 +1,68 @@
+package vision
+
+import (
+	"image"
+)
+
+// blobStep is the distance in pixels between the sampled pixels
+const blobStep = 2
+
+// Blob is an area of the frame in the colour being looked for
+type Blob struct {
+	// Bounds is the bounding box of the matching pixels
+	Bounds image.Rectangle
+	// Centre is the centre of mass of the matching pixels
+	Centre image.Point
+	// OffsetX and OffsetY are how far the centre is from the middle of the frame (-1 to 1). Positive values are to the right and down.
+	OffsetX, OffsetY float64
+	// Size is the fraction of the frame covered by the matching pixels (0-1)
+	Size float64
+}
+
+// FindBlob looks for the pixels of the colour in the frame and returns false if the matching area is smaller than minSize (0-1).
+// The frame is expected to show a single object of the colour. Every other pixel is sampled for speed.
+func FindBlob(img *image.RGBA, colour ColourRange, minSize float64) (Blob, bool) {
+	bounds := img.Bounds()
+	var count, sumX, sumY int
+	box := image.Rectangle{Min: bounds.Max, Max: bounds.Min}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += blobStep {
+		for x := bounds.Min.X; x < bounds.Max.X; x += blobStep {
+			i := img.PixOffset(x, y)
+			if !colour.Contains(img.Pix[i], img.Pix[i+1], img.Pix[i+2]) {
+				continue
+			}
+			count++
+			sumX += x
+			sumY += y
+			if x < box.Min.X {
+				box.Min.X = x
+			}
+			if y < box.Min.Y {
+				box.Min.Y = y
+			}
+			if x+blobStep > box.Max.X {
+				box.Max.X = x + blobStep
+			}
+			if y+blobStep > box.Max.Y {
+				box.Max.Y = y + blobStep
+			}
+		}
+	}
+	samples := ((bounds.Dx() + blobStep - 1) / blobStep) * ((bounds.Dy() + blobStep - 1) / blobStep)
+	if count == 0 || samples == 0 {
+		return Blob{}, false
+	}
+	size := float64(count) / float64(samples)
+	if size < minSize {
+		return Blob{}, false
+	}
+	centre := image.Pt(sumX/count, sumY/count)
+	halfWidth, halfHeight := float64(bounds.Dx())/2, float64(bounds.Dy())/2
+	return Blob{
+		Bounds:  box.Intersect(bounds),
+		Centre:  centre,
+		OffsetX: (float64(centre.X-bounds.Min.X) - halfWidth) / halfWidth,
+		OffsetY: (float64(centre.Y-bounds.Min.Y) - halfHeight) / halfHeight,
+		Size:    size,
+	}, true
+}
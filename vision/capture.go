@@ -0,0 +1,347 @@
+package vision
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/jpeg"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
+)
+
+const (
+	// capturePublisher is the name the camera routines publish the events under
+	capturePublisher = "capture"
+	// photoIndex is the file the metadata of the photos is saved to
+	photoIndex = "photos.json"
+)
+
+// CaptureMode is the kind of camera routine
+type CaptureMode int8
+
+const (
+	// Panorama turns the drone all the way round and takes a photo at each stop
+	Panorama CaptureMode = iota
+	// Timelapse takes a photo at regular intervals while the drone hovers
+	Timelapse
+)
+
+func (m CaptureMode) String() string {
+	switch m {
+	case Panorama:
+		return "panorama"
+	case Timelapse:
+		return "timelapse"
+	default:
+		return "Unknown"
+	}
+}
+
+// ParseCaptureMode converts the name of a camera routine (ie. panorama) to a CaptureMode
+func ParseCaptureMode(name string) (CaptureMode, error) {
+	for m := Panorama; m <= Timelapse; m++ {
+		if strings.EqualFold(m.String(), name) {
+			return m, nil
+		}
+	}
+	return Panorama, fmt.Errorf("unknown capture mode %q, expected panorama or timelapse", name)
+}
+
+// CaptureOptions are the settings of the camera routines
+type CaptureOptions struct {
+	Mode CaptureMode
+	// Dir is the directory the photos and their metadata are saved to
+	Dir string
+	// Turn is the angle in degrees the robot turns with each rotation command. The panorama stops every Turn degrees,
+	// so it needs a robot which turns a fixed angle (ie. the cw command of the SDK) rather than for as long as a key is held.
+	Turn float64
+	// Settle is how long the drone is given to steady itself before a photo is taken
+	Settle time.Duration
+	// Interval is the time between the photos of a timelapse
+	Interval time.Duration
+	// Count is the number of photos of a timelapse. Zero keeps taking photos until the routine is cancelled.
+	Count int
+}
+
+// DefaultCaptureOptions returns the settings of the camera routine. The turn of a panorama depends on the robot, so it's left unset.
+func DefaultCaptureOptions(mode CaptureMode, dir string) CaptureOptions {
+	return CaptureOptions{
+		Mode:     mode,
+		Dir:      dir,
+		Settle:   3 * time.Second,
+		Interval: 5 * time.Second,
+	}
+}
+
+// Photo is the metadata of a saved photo
+type Photo struct {
+	// File is the name of the photo in the capture directory
+	File string `json:"file"`
+	// Yaw is the heading in degrees since the routine has started, measured clockwise.
+	// The drone does not report its heading, so it's the number of rotations times the angle of each rotation.
+	Yaw float64 `json:"yaw"`
+	// Height is the height of the drone in decimetres
+	Height int `json:"height"`
+	// Time is when the photo has been taken
+	Time time.Time `json:"time"`
+}
+
+// Capture implements the Source interface and runs a camera routine using the frames of the video feed as photos.
+//
+// The pilot can position the drone first: the routine starts once the drone has been airborne and left alone for the settle period.
+// From then on, it's cancelled as soon as another source triggers a command (ie. the pilot has pressed a key).
+// A panorama sends a rotation after each photo and waits for it to be acknowledged and for the drone to steady itself before
+// the next photo, so the photos are exactly Turn degrees apart. Every photo is saved as JPEG and its metadata is added to photos.json.
+// The routine finds out about the pilot's commands from the CommandTriggered events, so it must share the event bus of the other sources.
+type Capture struct {
+	frames    <-chan Frame
+	commands  chan input.Command
+	options   CaptureOptions
+	telemetry func() Telemetry
+	logger    *logging.Logger
+	bus       *event.Bus
+	stops     int
+
+	mux       sync.Mutex
+	photos    []Photo
+	next      time.Time
+	airborne  time.Time
+	lastPilot time.Time
+	started   bool
+	finished  bool
+	gate      ackGate
+	rotations int
+}
+
+// NewCapture creates a new camera routine which takes the photos from the frames
+func NewCapture(frames <-chan Frame, options CaptureOptions, logger *logging.Logger) (*Capture, error) {
+	if options.Mode == Panorama && options.Turn != 0 {
+		if err := validateTurn(options.Turn); err != nil {
+			return nil, err
+		}
+	}
+	if options.Mode == Timelapse && options.Interval <= 0 {
+		return nil, fmt.Errorf("invalid timelapse interval %s", options.Interval)
+	}
+	if err := os.MkdirAll(options.Dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Capture{
+		frames:   frames,
+		commands: make(chan input.Command),
+		options:  options,
+		logger:   logger.With(logging.Fields{"source": capturePublisher}),
+		stops:    stopsFor(options.Turn),
+	}, nil
+}
+
+// SetTurn sets the angle in degrees the robot turns with each rotation command, which a panorama cannot do without.
+// It must be called before Start.
+func (c *Capture) SetTurn(turn float64) error {
+	if err := validateTurn(turn); err != nil {
+		return err
+	}
+	c.options.Turn = turn
+	c.stops = stopsFor(turn)
+	return nil
+}
+
+func validateTurn(turn float64) error {
+	if turn <= 0 || turn > 180 {
+		return fmt.Errorf("invalid panorama turn %g°, expected more than 0° and up to 180°", turn)
+	}
+	return nil
+}
+
+// stopsFor returns the number of photos of a panorama which turns the specified angle between the photos
+func stopsFor(turn float64) int {
+	if turn <= 0 {
+		return 0
+	}
+	return int(math.Round(360 / turn))
+}
+
+// SetEventBus sets the bus the photos and the triggered commands are published to.
+// The commands triggered by the other sources are read from the bus to cancel the routine.
+func (c *Capture) SetEventBus(bus *event.Bus) {
+	c.bus = bus
+}
+
+// SetTelemetry sets the function the state of the drone is read from. The routine starts straight away if it's not set.
+// It must be called before Start.
+func (c *Capture) SetTelemetry(telemetry func() Telemetry) {
+	c.telemetry = telemetry
+}
+
+func (c *Capture) Commands() <-chan input.Command {
+	return c.commands
+}
+
+// Start runs the routine and blocks until the frames channel is closed
+func (c *Capture) Start() error {
+	defer close(c.commands)
+	if c.options.Mode == Panorama && c.stops == 0 {
+		return fmt.Errorf("the panorama needs to know the angle the robot turns with each rotation")
+	}
+	triggered := c.bus.Subscribe(event.CommandTriggered)
+	defer triggered.Cancel()
+	go func() {
+		for e := range triggered.Events() {
+			if e.Publisher != capturePublisher {
+				c.pilot(e.Time)
+			}
+		}
+	}()
+	for frame := range c.frames {
+		if cmd, ok := c.step(frame); ok {
+			c.bus.Publish(event.New(event.CommandTriggered, capturePublisher, cmd))
+			c.commands <- cmd
+		}
+	}
+	return nil
+}
+
+// pilot records a command triggered by another source, which cancels the routine once it has started
+func (c *Capture) pilot(at time.Time) {
+	c.mux.Lock()
+	started := c.started
+	c.lastPilot = at
+	c.mux.Unlock()
+	if started {
+		c.Cancel()
+	}
+}
+
+// Cancel stops the routine. The photos which have been taken are kept.
+func (c *Capture) Cancel() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if !c.started || c.finished {
+		return
+	}
+	c.finished = true
+	c.logger.Infof("Capture: %s cancelled after %d photo(s)", c.options.Mode, len(c.photos))
+}
+
+// Acknowledge lets the panorama carry on once the drone has turned. The panorama is cancelled if the rotation has not been carried out.
+func (c *Capture) Acknowledge(result input.Result) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if !c.gate.acknowledge() {
+		// The routine has already carried on without the acknowledgement
+		return
+	}
+	if result.Outcome != input.Executed && !c.finished {
+		c.finished = true
+		c.logger.Infof("Capture: %s cancelled after %s was not executed", c.options.Mode, result.Command)
+		return
+	}
+	c.rotations++
+	c.next = time.Now().Add(c.options.Settle)
+}
+
+// step takes a photo if it's due and returns the rotation to send to the robot, if any
+func (c *Capture) step(frame Frame) (input.Command, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.finished {
+		return input.None, false
+	}
+	if !c.started {
+		if c.telemetry != nil && !c.telemetry().Airborne {
+			c.airborne = time.Time{}
+			return input.None, false
+		}
+		if c.airborne.IsZero() {
+			c.airborne = frame.At
+		}
+		if frame.At.Sub(c.airborne) < c.options.Settle || frame.At.Sub(c.lastPilot) < c.options.Settle {
+			return input.None, false
+		}
+		c.started = true
+		c.next = frame.At
+		c.logger.Infof("Capture: %s started", c.options.Mode)
+	}
+	if c.gate.waiting(frame.At) {
+		return input.None, false
+	}
+	if c.gate.expire(frame.At) {
+		c.rotations++
+		c.next = frame.At.Add(c.options.Settle)
+	}
+	if frame.At.Before(c.next) {
+		return input.None, false
+	}
+
+	if err := c.save(frame); err != nil {
+		c.logger.Errorf("Capture: %s", err)
+		c.finished = true
+		return input.None, false
+	}
+	if c.options.Mode == Timelapse {
+		c.next = frame.At.Add(c.options.Interval)
+		if c.options.Count > 0 && len(c.photos) >= c.options.Count {
+			c.finish()
+		}
+		return input.None, false
+	}
+	if len(c.photos) >= c.stops {
+		c.finish()
+		return input.None, false
+	}
+	c.gate.send(frame.At)
+	return input.RotateRight, true
+}
+
+func (c *Capture) finish() {
+	c.finished = true
+	c.logger.Infof("Capture: %s finished, %d photo(s) saved to %s", c.options.Mode, len(c.photos), c.options.Dir)
+}
+
+// save writes the frame as a photo and adds its metadata to the index
+func (c *Capture) save(frame Frame) error {
+	photo := Photo{
+		File: fmt.Sprintf("%s-%03d.jpg", c.options.Mode, len(c.photos)+1),
+		Time: frame.At,
+	}
+	if c.options.Mode == Panorama {
+		photo.Yaw = math.Mod(float64(c.rotations)*c.options.Turn, 360)
+	}
+	if c.telemetry != nil {
+		photo.Height = c.telemetry().Height
+	}
+	if err := writeJPEG(filepath.Join(c.options.Dir, photo.File), frame); err != nil {
+		return fmt.Errorf("failed to save %s: %s", photo.File, err)
+	}
+	c.photos = append(c.photos, photo)
+	index, err := json.MarshalIndent(c.photos, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(c.options.Dir, photoIndex), index, 0644); err != nil {
+		return fmt.Errorf("failed to save the metadata: %s", err)
+	}
+	c.logger.Log(logging.Info, logging.Fields{"yaw": photo.Yaw, "height": photo.Height}, "Capture: %s saved", photo.File)
+	c.bus.Publish(event.New(event.PhotoTaken, capturePublisher, photo))
+	return nil
+}
+
+func writeJPEG(path string, frame Frame) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(f, frame.Image, nil); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,41 @@
+package vision
+
+import (
+	"testing"
+
+	"github.com/xitonix/gophobotics/logging"
+)
+
+func TestPanoramaNeedsTheTurn(t *testing.T) {
+	testCases := []struct {
+		title string
+		turn  float64
+		stops int
+		valid bool
+	}{
+		{title: "quarter turns", turn: 90, stops: 4, valid: true},
+		{title: "odd angle", turn: 50, stops: 7, valid: true},
+		{title: "no turn", turn: 0},
+		{title: "more than half a turn", turn: 270},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			frames := make(chan Frame)
+			close(frames)
+			capture, err := NewCapture(frames, DefaultCaptureOptions(Panorama, t.TempDir()), logging.New(logging.Debug, logging.NewMemory()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = capture.SetTurn(tc.turn)
+			if tc.valid != (err == nil) {
+				t.Fatalf("Expected valid to be %v, got %v", tc.valid, err)
+			}
+			if capture.stops != tc.stops {
+				t.Errorf("Expected %d stops, got %d", tc.stops, capture.stops)
+			}
+			if err := capture.Start(); tc.valid != (err == nil) {
+				t.Errorf("Expected the panorama to start to be %v, got %v", tc.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package vision
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ColourRange is a range of colours in the HSV space
+type ColourRange struct {
+	// Name is the name of the colour
+	Name string
+	// HueMin and HueMax are the hue range in degrees (0-360). The range wraps around if HueMin is greater than HueMax.
+	HueMin, HueMax float64
+	// MinSaturation and MinValue filter out the greyish and the dark pixels (0-1)
+	MinSaturation, MinValue float64
+}
+
+// colours are the predefined colour ranges by name
+var colours = map[string]ColourRange{
+	"red":    {Name: "red", HueMin: 345, HueMax: 15, MinSaturation: 0.5, MinValue: 0.3},
+	"orange": {Name: "orange", HueMin: 15, HueMax: 40, MinSaturation: 0.5, MinValue: 0.3},
+	"yellow": {Name: "yellow", HueMin: 40, HueMax: 70, MinSaturation: 0.5, MinValue: 0.3},
+	"green":  {Name: "green", HueMin: 80, HueMax: 160, MinSaturation: 0.4, MinValue: 0.2},
+	"blue":   {Name: "blue", HueMin: 190, HueMax: 250, MinSaturation: 0.4, MinValue: 0.2},
+	"pink":   {Name: "pink", HueMin: 290, HueMax: 345, MinSaturation: 0.4, MinValue: 0.3},
+}
+
+// Colours returns the names of the predefined colours
+func Colours() []string {
+	names := make([]string, 0, len(colours))
+	for name := range colours {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseColour returns the predefined colour with the specified name, or a custom hue range in degrees (ie. 100-140)
+func ParseColour(value string) (ColourRange, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if c, ok := colours[value]; ok {
+		return c, nil
+	}
+	parts := strings.SplitN(value, "-", 2)
+	if len(parts) == 2 {
+		min, minErr := strconv.ParseFloat(parts[0], 64)
+		max, maxErr := strconv.ParseFloat(parts[1], 64)
+		if minErr == nil && maxErr == nil && min >= 0 && min <= 360 && max >= 0 && max <= 360 {
+			return ColourRange{Name: value, HueMin: min, HueMax: max, MinSaturation: 0.4, MinValue: 0.2}, nil
+		}
+	}
+	return ColourRange{}, fmt.Errorf("unknown colour %q, expected %s or a hue range (ie. 100-140)", value, strings.Join(Colours(), ", "))
+}
+
+// Contains returns true if the RGB colour is within the range
+func (c ColourRange) Contains(r, g, b uint8) bool {
+	h, s, v := hsv(r, g, b)
+	if s < c.MinSaturation || v < c.MinValue {
+		return false
+	}
+	if c.HueMin <= c.HueMax {
+		return h >= c.HueMin && h <= c.HueMax
+	}
+	return h >= c.HueMin || h <= c.HueMax
+}
+
+func (c ColourRange) String() string {
+	return c.Name
+}
+
+// hsv converts an RGB colour to hue (0-360), saturation (0-1) and value (0-1)
+func hsv(r, g, b uint8) (float64, float64, float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	delta := max - min
+	if max == 0 {
+		return 0, 0, 0
+	}
+	s := delta / max
+	if delta == 0 {
+		return 0, s, max
+	}
+	var h float64
+	switch max {
+	case rf:
+		h = math.Mod((gf-bf)/delta, 6)
+	case gf:
+		h = (bf-rf)/delta + 2
+	default:
+		h = (rf-gf)/delta + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s, max
+}
@@ -0,0 +1,228 @@
+package vision
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xitonix/gophobotics/logging"
+)
+
+// Options are the settings of the decoder
+type Options struct {
+	// Width and Height are the size of the frames in pixels
+	Width, Height int
+	// Rate is the number of frames per second
+	Rate float64
+	// Buffer is the number of frames which are queued before the oldest ones get dropped
+	Buffer int
+	// FFmpeg is the path to the ffmpeg executable
+	FFmpeg string
+	// Realtime reads a recorded file at its native rate instead of as fast as possible
+	Realtime bool
+}
+
+// DefaultOptions returns half the resolution of the Tello camera at 10 frames per second
+func DefaultOptions() Options {
+	return Options{
+		Width:  480,
+		Height: 360,
+		Rate:   10,
+		Buffer: 2,
+		FFmpeg: "ffmpeg",
+	}
+}
+
+// Decoder decodes an H.264 video feed into frames using an ffmpeg subprocess.
+//
+// The feed is written to the decoder, so it can be passed to the Video method of the robots in place of a video player.
+// A live feed is never held up by a slow receiver: once the buffer is full, the oldest frame is dropped to make room for the latest one.
+// A recorded file is decoded as fast as the receiver reads the frames instead, unless it's played in real time.
+type Decoder struct {
+	options Options
+	cmd     *exec.Cmd
+	input   io.WriteCloser
+	frames  chan Frame
+	logger  *logging.Logger
+	stderr  bytes.Buffer
+	done    chan interface{}
+	err     error
+
+	closeOnce sync.Once
+	meter     atomic.Value
+	decoded   uint64
+	dropped   uint64
+}
+
+// NewDecoder starts a decoder which reads the raw H.264 feed written to it
+func NewDecoder(options Options, logger *logging.Logger) (*Decoder, error) {
+	return start(options, "pipe:0", logger)
+}
+
+// DecodeFile starts a decoder which reads a recorded video file instead of a live feed.
+// Nothing must be written to the decoder, and the Frames channel is closed once the end of the file has been reached.
+func DecodeFile(path string, options Options, logger *logging.Logger) (*Decoder, error) {
+	return start(options, path, logger)
+}
+
+func start(options Options, input string, logger *logging.Logger) (*Decoder, error) {
+	if options.Width <= 0 || options.Height <= 0 || options.Rate <= 0 {
+		return nil, fmt.Errorf("invalid frame size %dx%d at %g fps", options.Width, options.Height, options.Rate)
+	}
+	if options.Buffer < 1 {
+		options.Buffer = 1
+	}
+	args := []string{"-loglevel", "error"}
+	if input == "pipe:0" {
+		// The drone feed has no container, so the format cannot be probed reliably
+		args = append(args, "-f", "h264")
+	} else {
+		args = append(args, "-nostdin")
+		if options.Realtime {
+			args = append(args, "-re")
+		}
+	}
+	args = append(args,
+		"-i", input,
+		"-an",
+		"-vf", fmt.Sprintf("fps=%g,scale=%d:%d", options.Rate, options.Width, options.Height),
+		"-f", "rawvideo",
+		"-pix_fmt", "rgba",
+		"pipe:1",
+	)
+
+	d := &Decoder{
+		options: options,
+		cmd:     exec.Command(options.FFmpeg, args...),
+		frames:  make(chan Frame, options.Buffer),
+		logger:  logger,
+		done:    make(chan interface{}),
+	}
+	d.cmd.Stderr = &d.stderr
+	if input == "pipe:0" {
+		stdin, err := d.cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		d.input = stdin
+	}
+	stdout, err := d.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %s", options.FFmpeg, err)
+	}
+	go d.read(stdout)
+	return d, nil
+}
+
+// Write feeds the decoder with the raw H.264 video
+func (d *Decoder) Write(p []byte) (int, error) {
+	if d.input == nil {
+		return 0, fmt.Errorf("the decoder is reading a file")
+	}
+	return d.input.Write(p)
+}
+
+// Frames returns the decoded frames. The channel is closed once the decoder has stopped.
+func (d *Decoder) Frames() <-chan Frame {
+	return d.frames
+}
+
+// SetMeter sets the function the brightness of every decoded frame is reported to (ie. to adjust the exposure of the camera)
+func (d *Decoder) SetMeter(meter func(brightness float64)) {
+	d.meter.Store(meter)
+}
+
+// Stats returns the frame counters of the decoder
+func (d *Decoder) Stats() Stats {
+	return Stats{
+		Decoded: atomic.LoadUint64(&d.decoded),
+		Dropped: atomic.LoadUint64(&d.dropped),
+	}
+}
+
+// Close stops feeding the decoder and waits for the remaining frames to be decoded.
+// The frames which are still in the buffer can be read after Close has returned.
+func (d *Decoder) Close() error {
+	d.closeOnce.Do(func() {
+		if d.input != nil {
+			_ = d.input.Close()
+		} else if d.cmd.Process != nil {
+			// A file is decoded to the end unless the decoder is stopped
+			select {
+			case <-d.done:
+			default:
+				_ = d.cmd.Process.Kill()
+			}
+		}
+	})
+	<-d.done
+	return d.err
+}
+
+// Wait blocks until the decoder has stopped and returns the reason if it has failed
+func (d *Decoder) Wait() error {
+	<-d.done
+	return d.err
+}
+
+func (d *Decoder) read(stdout io.Reader) {
+	defer close(d.done)
+	defer close(d.frames)
+
+	size := d.options.Width * d.options.Height * 4
+	var seq uint64
+	for {
+		img := image.NewRGBA(image.Rect(0, 0, d.options.Width, d.options.Height))
+		if _, err := io.ReadFull(stdout, img.Pix[:size]); err != nil {
+			break
+		}
+		seq++
+		atomic.AddUint64(&d.decoded, 1)
+		if meter, ok := d.meter.Load().(func(float64)); ok {
+			meter(Brightness(img))
+		}
+		d.deliver(Frame{Seq: seq, At: time.Now(), Image: img})
+	}
+
+	if err := d.cmd.Wait(); err != nil {
+		if message := strings.TrimSpace(d.stderr.String()); message != "" {
+			err = fmt.Errorf("%s: %s", err, message)
+		}
+		// Killing a file decoder is not a failure
+		if d.input != nil || !strings.Contains(err.Error(), "killed") {
+			d.err = err
+			d.logger.Errorf("Video: Decoder stopped: %s", err)
+		}
+	}
+	d.logger.Debugf("Video: %d frames decoded, %d dropped", atomic.LoadUint64(&d.decoded), atomic.LoadUint64(&d.dropped))
+}
+
+// deliver queues the frame, dropping the oldest frame if the buffer of a live feed is full
+func (d *Decoder) deliver(frame Frame) {
+	if d.input == nil && !d.options.Realtime {
+		// ffmpeg is held up until the receiver catches up
+		d.frames <- frame
+		return
+	}
+	for {
+		select {
+		case d.frames <- frame:
+			return
+		default:
+		}
+		select {
+		case <-d.frames:
+			atomic.AddUint64(&d.dropped, 1)
+		default:
+		}
+	}
+}
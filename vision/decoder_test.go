@@ -0,0 +1,83 @@
+package vision
+
+import (
+	"testing"
+)
+
+type nopWriteCloser struct{}
+
+func (nopWriteCloser) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func TestDeliverDropsTheOldestFrames(t *testing.T) {
+	testCases := []struct {
+		title    string
+		buffer   int
+		frames   int
+		expected []uint64
+		dropped  uint64
+	}{
+		{title: "within the buffer", buffer: 3, frames: 2, expected: []uint64{1, 2}},
+		{title: "full buffer", buffer: 2, frames: 2, expected: []uint64{1, 2}},
+		{title: "one frame over", buffer: 2, frames: 3, expected: []uint64{2, 3}, dropped: 1},
+		{title: "slow receiver", buffer: 2, frames: 10, expected: []uint64{9, 10}, dropped: 8},
+		{title: "single frame buffer", buffer: 1, frames: 5, expected: []uint64{5}, dropped: 4},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			// A live feed is written to the decoder
+			d := &Decoder{
+				options: Options{Buffer: tc.buffer},
+				input:   nopWriteCloser{},
+				frames:  make(chan Frame, tc.buffer),
+			}
+			for seq := 1; seq <= tc.frames; seq++ {
+				d.deliver(Frame{Seq: uint64(seq)})
+			}
+			close(d.frames)
+
+			var received []uint64
+			for frame := range d.frames {
+				received = append(received, frame.Seq)
+			}
+			if len(received) != len(tc.expected) {
+				t.Fatalf("Expected frames %v, got %v", tc.expected, received)
+			}
+			for i, seq := range tc.expected {
+				if received[i] != seq {
+					t.Errorf("Expected frames %v, got %v", tc.expected, received)
+					break
+				}
+			}
+			if stats := d.Stats(); stats.Dropped != tc.dropped {
+				t.Errorf("Expected %d dropped frames, got %d", tc.dropped, stats.Dropped)
+			}
+		})
+	}
+}
+
+func TestDeliverHoldsUpTheRecordedFiles(t *testing.T) {
+	d := &Decoder{frames: make(chan Frame, 1)}
+	d.deliver(Frame{Seq: 1})
+
+	delivered := make(chan interface{})
+	go func() {
+		d.deliver(Frame{Seq: 2})
+		close(delivered)
+	}()
+	if first := <-d.frames; first.Seq != 1 {
+		t.Errorf("Expected the first frame not to be dropped, got frame %d", first.Seq)
+	}
+	<-delivered
+	if second := <-d.frames; second.Seq != 2 {
+		t.Errorf("Expected the second frame, got frame %d", second.Seq)
+	}
+	if stats := d.Stats(); stats.Dropped != 0 {
+		t.Errorf("Expected no dropped frames, got %d", stats.Dropped)
+	}
+}
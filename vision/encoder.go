@@ -0,0 +1,146 @@
+package vision
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xitonix/gophobotics/logging"
+)
+
+// Encoder encodes frames into an H.264 video file using an ffmpeg subprocess.
+//
+// The frames are placed in the video by their timestamp: the previous frame is repeated to fill the gaps left by the dropped frames,
+// and the frames which arrive ahead of time are skipped, so the video plays at the same pace as the flight.
+// The time the recording has been paused for is left out of the video.
+type Encoder struct {
+	options Options
+	path    string
+	cmd     *exec.Cmd
+	input   io.WriteCloser
+	stderr  bytes.Buffer
+	logger  *logging.Logger
+
+	mux      sync.Mutex
+	start    time.Time
+	written  uint64
+	previous []byte
+	closed   bool
+	paused   bool
+	resumed  bool
+}
+
+// NewEncoder starts an encoder which writes the frames of the specified size and rate to the file.
+// The format is chosen by ffmpeg from the file extension (ie. .mp4 or .mkv). An existing file is overwritten.
+func NewEncoder(path string, options Options, logger *logging.Logger) (*Encoder, error) {
+	if options.Width <= 0 || options.Height <= 0 || options.Rate <= 0 {
+		return nil, fmt.Errorf("invalid frame size %dx%d at %g fps", options.Width, options.Height, options.Rate)
+	}
+	args := []string{
+		"-loglevel", "error",
+		"-y",
+		"-f", "rawvideo",
+		"-pix_fmt", "rgba",
+		"-s", fmt.Sprintf("%dx%d", options.Width, options.Height),
+		"-r", fmt.Sprintf("%g", options.Rate),
+		"-i", "pipe:0",
+		"-an",
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-pix_fmt", "yuv420p",
+		path,
+	}
+	e := &Encoder{
+		options: options,
+		path:    path,
+		cmd:     exec.Command(options.FFmpeg, args...),
+		logger:  logger,
+	}
+	e.cmd.Stderr = &e.stderr
+	stdin, err := e.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	e.input = stdin
+	if err := e.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %s", options.FFmpeg, err)
+	}
+	return e, nil
+}
+
+// Encode adds the frame to the video at the position of its timestamp
+func (e *Encoder) Encode(frame Frame) error {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	if e.closed {
+		return fmt.Errorf("the encoder has been closed")
+	}
+	size := e.options.Width * e.options.Height * 4
+	if frame.Image == nil || frame.Image.Bounds().Dx() != e.options.Width || frame.Image.Bounds().Dy() != e.options.Height {
+		return fmt.Errorf("the frame size does not match %dx%d", e.options.Width, e.options.Height)
+	}
+	if e.paused {
+		return nil
+	}
+	if e.start.IsZero() {
+		e.start = frame.At
+	}
+	if e.resumed {
+		// The video carries on from where it has been paused
+		e.start = frame.At.Add(-time.Duration(float64(e.written) / e.options.Rate * float64(time.Second)))
+		e.resumed = false
+	}
+	position := uint64(math.Round(frame.At.Sub(e.start).Seconds() * e.options.Rate))
+	if e.written > position {
+		return nil
+	}
+	for e.previous != nil && e.written < position {
+		if _, err := e.input.Write(e.previous); err != nil {
+			return fmt.Errorf("failed to write the frame: %s", err)
+		}
+		e.written++
+	}
+	pixels := frame.Image.Pix[:size]
+	if _, err := e.input.Write(pixels); err != nil {
+		return fmt.Errorf("failed to write the frame: %s", err)
+	}
+	e.written++
+	e.previous = append(e.previous[:0], pixels...)
+	return nil
+}
+
+// Toggle pauses or resumes the recording. It returns true if the frames are being recorded.
+func (e *Encoder) Toggle() bool {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.paused = !e.paused
+	if !e.paused && !e.start.IsZero() {
+		e.resumed = true
+	}
+	return !e.paused
+}
+
+// Close finishes the video file. It must be called once all the frames have been encoded.
+func (e *Encoder) Close() error {
+	e.mux.Lock()
+	if e.closed {
+		e.mux.Unlock()
+		return nil
+	}
+	e.closed = true
+	e.mux.Unlock()
+	_ = e.input.Close()
+	if err := e.cmd.Wait(); err != nil {
+		if message := strings.TrimSpace(e.stderr.String()); message != "" {
+			return fmt.Errorf("%s: %s", err, message)
+		}
+		return err
+	}
+	e.logger.Debugf("Video: %d frames written to %s", e.written, e.path)
+	return nil
+}
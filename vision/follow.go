@@ -0,0 +1,170 @@
+package vision
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
+)
+
+// followPublisher is the name the follower publishes the events under
+const followPublisher = "follow"
+
+// FollowOptions are the settings of the follow mode
+type FollowOptions struct {
+	// Colour is the colour of the object to follow
+	Colour ColourRange
+	// MinSize is the smallest fraction of the frame the object must cover to be detected (0-1)
+	MinSize float64
+	// TargetSize is the fraction of the frame the object should cover, which sets the distance to keep (0-1)
+	TargetSize float64
+	// SizeTolerance is how far the size can be from the target size before the drone moves, relative to the target size
+	SizeTolerance float64
+	// DeadZone is how far the object can be from the middle of the frame before the drone turns or climbs (0-1)
+	DeadZone float64
+	// Interval is the minimum period between two corrections
+	Interval time.Duration
+	// Backoff is how long the follower waits after a correction has not been carried out (ie. the pilot has taken over)
+	Backoff time.Duration
+}
+
+// DefaultFollowOptions returns the follow settings for a ball held a couple of metres away from the drone
+func DefaultFollowOptions(colour ColourRange) FollowOptions {
+	return FollowOptions{
+		Colour:        colour,
+		MinSize:       0.002,
+		TargetSize:    0.05,
+		SizeTolerance: 0.4,
+		DeadZone:      0.2,
+		Interval:      700 * time.Millisecond,
+		Backoff:       2 * time.Second,
+	}
+}
+
+// Follower implements the Source interface and steers the drone towards an object of a specific colour.
+//
+// Every frame is searched for the colour and the largest error is turned into a correction: the drone turns to keep the object
+// in the middle of the frame horizontally, climbs or descends to keep it in the middle vertically, and moves forward or backward
+// to keep its apparent size. Only one correction is in flight at a time. Nothing is sent while the object is out of sight.
+// A correction which has not been carried out pauses the follower for the backoff period, which leaves the drone to the pilot
+// when the follower is given a lower priority than the keyboard.
+type Follower struct {
+	frames   <-chan Frame
+	commands chan input.Command
+	options  FollowOptions
+	logger   *logging.Logger
+	bus      *event.Bus
+
+	mux         sync.Mutex
+	gate        ackGate
+	pausedUntil time.Time
+	seen        bool
+}
+
+// NewFollower creates a new follow mode source which analyses the frames
+func NewFollower(frames <-chan Frame, options FollowOptions, logger *logging.Logger) *Follower {
+	return &Follower{
+		frames:   frames,
+		commands: make(chan input.Command),
+		options:  options,
+		logger:   logger.With(logging.Fields{"source": followPublisher}),
+	}
+}
+
+// SetEventBus sets the bus the triggered commands are published to
+func (f *Follower) SetEventBus(bus *event.Bus) {
+	f.bus = bus
+}
+
+func (f *Follower) Commands() <-chan input.Command {
+	return f.commands
+}
+
+// Start analyses the frames and blocks until the frames channel is closed
+func (f *Follower) Start() error {
+	defer close(f.commands)
+	for frame := range f.frames {
+		blob, found := FindBlob(frame.Image, f.options.Colour, f.options.MinSize)
+		f.track(found)
+		if !found || !f.ready(frame.At) {
+			continue
+		}
+		cmd := Correction(blob, f.options)
+		if cmd == input.None {
+			continue
+		}
+		f.mux.Lock()
+		f.gate.send(frame.At)
+		f.mux.Unlock()
+		f.logger.Log(logging.Debug, logging.Fields{"command": cmd, "offset_x": blob.OffsetX, "offset_y": blob.OffsetY, "size": blob.Size}, "Follow: %s", cmd)
+		f.bus.Publish(event.New(event.CommandTriggered, followPublisher, cmd))
+		f.commands <- cmd
+	}
+	return nil
+}
+
+// Acknowledge allows the next correction to be sent. The follower backs off if the correction has not been carried out.
+func (f *Follower) Acknowledge(result input.Result) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.gate.acknowledge()
+	if result.Outcome != input.Executed {
+		f.pausedUntil = time.Now().Add(f.options.Backoff)
+	}
+}
+
+// ready returns true if the next correction can be sent
+func (f *Follower) ready(now time.Time) bool {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return !f.gate.waiting(now) && now.After(f.pausedUntil) && f.gate.since(now) >= f.options.Interval
+}
+
+// track logs when the object comes into sight or gets lost
+func (f *Follower) track(found bool) {
+	if found == f.seen {
+		return
+	}
+	f.seen = found
+	if found {
+		f.logger.Infof("Follow: %s object found", f.options.Colour)
+		return
+	}
+	f.logger.Infof("Follow: %s object lost", f.options.Colour)
+}
+
+// Correction returns the command which reduces the largest error between where the object is and where it should be
+func Correction(blob Blob, options FollowOptions) input.Command {
+	// Each error is scaled by its own tolerance, so they can be compared
+	horizontal := math.Abs(blob.OffsetX) / options.DeadZone
+	vertical := math.Abs(blob.OffsetY) / options.DeadZone
+	distance := 0.0
+	if options.TargetSize > 0 {
+		distance = math.Abs(blob.Size-options.TargetSize) / (options.TargetSize * options.SizeTolerance)
+	}
+
+	largest := math.Max(horizontal, math.Max(vertical, distance))
+	if largest <= 1 {
+		return input.None
+	}
+	switch largest {
+	case horizontal:
+		if blob.OffsetX > 0 {
+			return input.RotateRight
+		}
+		return input.RotateLeft
+	case vertical:
+		if blob.OffsetY > 0 {
+			return input.Down
+		}
+		return input.Up
+	default:
+		if blob.Size < options.TargetSize {
+			return input.Forward
+		}
+		return input.Backward
+	}
+}
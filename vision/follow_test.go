@@ -0,0 +1,85 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+
+	"github.com/xitonix/gophobotics/input"
+)
+
+// frameWith returns a grey frame with a red square drawn on it
+func frameWith(square image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 128, G: 128, B: 128, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, square, image.NewUniform(color.RGBA{R: 230, G: 20, B: 20, A: 255}), image.Point{}, draw.Src)
+	return img
+}
+
+func TestFindBlob(t *testing.T) {
+	testCases := []struct {
+		title            string
+		square           image.Rectangle
+		minSize          float64
+		found            bool
+		offsetX, offsetY float64
+		size             float64
+	}{
+		{title: "no object", minSize: 0.001},
+		{title: "in the middle", square: image.Rect(40, 40, 60, 60), minSize: 0.001, found: true, offsetX: -0.02, offsetY: -0.02, size: 0.04},
+		{title: "top left", square: image.Rect(0, 0, 20, 20), minSize: 0.001, found: true, offsetX: -0.82, offsetY: -0.82, size: 0.04},
+		{title: "bottom right", square: image.Rect(80, 80, 100, 100), minSize: 0.001, found: true, offsetX: 0.78, offsetY: 0.78, size: 0.04},
+		{title: "smaller than the minimum", square: image.Rect(40, 40, 50, 50), minSize: 0.05},
+	}
+	red, _ := ParseColour("red")
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			blob, found := FindBlob(frameWith(tc.square), red, tc.minSize)
+			if found != tc.found {
+				t.Fatalf("Expected found to be %v, got %v", tc.found, found)
+			}
+			if !found {
+				return
+			}
+			if math.Abs(blob.OffsetX-tc.offsetX) > 0.001 || math.Abs(blob.OffsetY-tc.offsetY) > 0.001 {
+				t.Errorf("Expected the offset to be (%g, %g), got (%g, %g)", tc.offsetX, tc.offsetY, blob.OffsetX, blob.OffsetY)
+			}
+			if math.Abs(blob.Size-tc.size) > 0.001 {
+				t.Errorf("Expected the size to be %g, got %g", tc.size, blob.Size)
+			}
+			if blob.Bounds != tc.square {
+				t.Errorf("Expected the bounds to be %v, got %v", tc.square, blob.Bounds)
+			}
+		})
+	}
+}
+
+func TestCorrection(t *testing.T) {
+	testCases := []struct {
+		title    string
+		blob     Blob
+		expected input.Command
+	}{
+		{title: "on target", blob: Blob{OffsetX: 0.1, OffsetY: -0.1, Size: 0.05}, expected: input.None},
+		{title: "within the size tolerance", blob: Blob{Size: 0.065}, expected: input.None},
+		{title: "to the right", blob: Blob{OffsetX: 0.5, Size: 0.05}, expected: input.RotateRight},
+		{title: "to the left", blob: Blob{OffsetX: -0.5, Size: 0.05}, expected: input.RotateLeft},
+		{title: "above", blob: Blob{OffsetY: -0.5, Size: 0.05}, expected: input.Up},
+		{title: "below", blob: Blob{OffsetY: 0.5, Size: 0.05}, expected: input.Down},
+		{title: "too far", blob: Blob{Size: 0.01}, expected: input.Forward},
+		{title: "too close", blob: Blob{Size: 0.2}, expected: input.Backward},
+		{title: "horizontal error is the largest", blob: Blob{OffsetX: 0.8, OffsetY: 0.5, Size: 0.05}, expected: input.RotateRight},
+		{title: "vertical error is the largest", blob: Blob{OffsetX: 0.3, OffsetY: -0.6, Size: 0.05}, expected: input.Up},
+		{title: "distance error is the largest", blob: Blob{OffsetX: 0.3, Size: 0.2}, expected: input.Backward},
+	}
+	options := DefaultFollowOptions(ColourRange{})
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			if actual := Correction(tc.blob, options); actual != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Package vision turns the video feed of the drones into frames which can be analysed in Go.
+package vision
+
+import (
+	"image"
+	"time"
+)
+
+// Frame is a decoded picture of the video feed
+type Frame struct {
+	// Seq is the sequence number of the frame since the decoder has started, including the dropped frames
+	Seq uint64
+	// At is when the frame has been decoded
+	At time.Time
+	// Image is the picture. It belongs to the receiver of the frame.
+	Image *image.RGBA
+}
+
+// Stats are the frame counters of a decoder
+type Stats struct {
+	// Decoded is the number of frames produced by the decoder
+	Decoded uint64
+	// Dropped is the number of frames thrown away because the receiver could not keep up
+	Dropped uint64
+}
+
+// brightnessStep is the distance in pixels between the samples the brightness is measured from
+const brightnessStep = 4
+
+// Brightness returns the mean brightness (0-255) of the picture, measured on a grid of pixels
+func Brightness(img *image.RGBA) float64 {
+	b := img.Bounds()
+	var sum, samples float64
+	for y := b.Min.Y; y < b.Max.Y; y += brightnessStep {
+		for x := b.Min.X; x < b.Max.X; x += brightnessStep {
+			p := img.PixOffset(x, y)
+			// Rec. 601 luma
+			sum += 0.299*float64(img.Pix[p]) + 0.587*float64(img.Pix[p+1]) + 0.114*float64(img.Pix[p+2])
+			samples++
+		}
+	}
+	if samples == 0 {
+		return 0
+	}
+	return sum / samples
+}
@@ -0,0 +1,262 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"strings"
+)
+
+const (
+	// MaxMarkerID is the highest ID a marker can encode
+	MaxMarkerID = 1023
+	// markerCells is the number of cells on each side of a marker, including the black border
+	markerCells = 7
+	// dataCells is the number of data cells on each side of a marker
+	dataCells = markerCells - 2
+	// minMarkerSize is the smallest marker in pixels which can be read
+	minMarkerSize = 21
+)
+
+// markerRows are the rows of data cells of the original ArUco dictionary, by the two bits they carry. True is a white cell.
+// The second and the fourth cells are the bits, the other three are the parity bits.
+var markerRows = [4][dataCells]bool{
+	{true, false, false, false, false},
+	{true, false, true, true, true},
+	{false, true, false, false, true},
+	{false, true, true, true, false},
+}
+
+// Marker is a square marker detected in a frame.
+//
+// The markers are from the original ArUco dictionary (DICT_ARUCO_ORIGINAL in OpenCV): a 7x7 grid of cells with a black border
+// around 5x5 data cells, printed on a white background. Each row of data cells carries two bits of the 10 bit ID, most significant first.
+// Use WriteMarkerSVG or any ArUco generator to print the markers.
+// The markers are read facing the camera, upright or turned by a multiple of 90°. A marker tilted in between is not read.
+type Marker struct {
+	// ID is the number encoded in the marker (0-1023)
+	ID int
+	// Bounds is where the marker is in the frame
+	Bounds image.Rectangle
+}
+
+// DetectMarkers finds the markers in the frame. The markers must face the camera, upright or turned by a multiple of 90°.
+func DetectMarkers(img *image.RGBA) []Marker {
+	dark := binarise(img)
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	visited := make([]bool, len(dark))
+	var markers []Marker
+	queue := make([]int, 0, 1024)
+	for start := range dark {
+		if !dark[start] || visited[start] {
+			continue
+		}
+		// Flood fill the dark region and keep its bounding box
+		box := image.Rectangle{Min: image.Pt(width, height)}
+		queue = append(queue[:0], start)
+		visited[start] = true
+		for len(queue) > 0 {
+			i := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			x, y := i%width, i/width
+			box.Min.X, box.Min.Y = minInt(box.Min.X, x), minInt(box.Min.Y, y)
+			box.Max.X, box.Max.Y = maxInt(box.Max.X, x+1), maxInt(box.Max.Y, y+1)
+			for _, n := range [4]int{i - 1, i + 1, i - width, i + width} {
+				if n < 0 || n >= len(dark) || visited[n] || !dark[n] {
+					continue
+				}
+				// Do not wrap around the edges of the frame
+				if (n == i-1 || n == i+1) && n/width != y {
+					continue
+				}
+				visited[n] = true
+				queue = append(queue, n)
+			}
+		}
+		if id, ok := readMarker(dark, width, box); ok {
+			markers = append(markers, Marker{ID: id, Bounds: box.Add(bounds.Min)})
+		}
+	}
+	return markers
+}
+
+// readMarker decodes the cells within the bounding box of a dark region
+func readMarker(dark []bool, width int, box image.Rectangle) (int, bool) {
+	w, h := box.Dx(), box.Dy()
+	if w < minMarkerSize || h < minMarkerSize || w*4 < h*3 || h*4 < w*3 {
+		return 0, false
+	}
+	var cells [markerCells][markerCells]bool
+	for row := 0; row < markerCells; row++ {
+		for col := 0; col < markerCells; col++ {
+			// Sample the middle of the cell, away from the blurry edges
+			x0 := box.Min.X + (2*col+1)*w/(2*markerCells)
+			y0 := box.Min.Y + (2*row+1)*h/(2*markerCells)
+			r := maxInt(1, w/(markerCells*4))
+			var black, total int
+			for y := y0 - r; y <= y0+r; y++ {
+				for x := x0 - r; x <= x0+r; x++ {
+					total++
+					if dark[y*width+x] {
+						black++
+					}
+				}
+			}
+			cells[row][col] = black*2 > total
+		}
+	}
+	for i := 0; i < markerCells; i++ {
+		if !cells[0][i] || !cells[markerCells-1][i] || !cells[i][0] || !cells[i][markerCells-1] {
+			return 0, false
+		}
+	}
+	var data [dataCells][dataCells]bool
+	for row := 0; row < dataCells; row++ {
+		for col := 0; col < dataCells; col++ {
+			// The bits of the dictionary are the white cells
+			data[row][col] = !cells[row+1][col+1]
+		}
+	}
+	for rotation := 0; rotation < 4; rotation++ {
+		if id, ok := decodeMarker(data); ok {
+			return id, true
+		}
+		data = rotate(data)
+	}
+	return 0, false
+}
+
+// decodeMarker reads the ID from the data cells if they are in the upright position
+func decodeMarker(data [dataCells][dataCells]bool) (int, bool) {
+	id := 0
+	for _, row := range data {
+		bits := -1
+		for value, expected := range markerRows {
+			if row == expected {
+				bits = value
+				break
+			}
+		}
+		if bits < 0 {
+			return 0, false
+		}
+		id = id<<2 | bits
+	}
+	return id, true
+}
+
+// rotate turns the cells 90° clockwise
+func rotate(data [dataCells][dataCells]bool) [dataCells][dataCells]bool {
+	var rotated [dataCells][dataCells]bool
+	for row := 0; row < dataCells; row++ {
+		for col := 0; col < dataCells; col++ {
+			rotated[col][dataCells-1-row] = data[row][col]
+		}
+	}
+	return rotated
+}
+
+// markerCellsFor returns the cells of the marker with the specified ID, including the border. True is a black cell.
+func markerCellsFor(id int) [markerCells][markerCells]bool {
+	var cells [markerCells][markerCells]bool
+	for i := 0; i < markerCells; i++ {
+		cells[0][i], cells[markerCells-1][i], cells[i][0], cells[i][markerCells-1] = true, true, true, true
+	}
+	for row := 0; row < dataCells; row++ {
+		bits := (id >> uint(2*(dataCells-1-row))) & 3
+		for col, white := range markerRows[bits] {
+			cells[row+1][col+1] = !white
+		}
+	}
+	return cells
+}
+
+// WriteMarkerSVG writes a printable marker with the specified ID. The size is the width of the marker in millimetres,
+// which is surrounded by a white margin of one cell.
+func WriteMarkerSVG(w io.Writer, id int, size float64) error {
+	if id < 0 || id > MaxMarkerID {
+		return fmt.Errorf("invalid marker ID %d, expected 0-%d", id, MaxMarkerID)
+	}
+	cells := markerCellsFor(id)
+	cell := size / markerCells
+	total := size + 2*cell
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %g %g" width="%gmm" height="%gmm">`+"\n", total, total, total, total)
+	fmt.Fprintf(&sb, `  <title>Marker %d</title>`+"\n", id)
+	fmt.Fprintf(&sb, `  <rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	for row := 0; row < markerCells; row++ {
+		for col := 0; col < markerCells; col++ {
+			if cells[row][col] {
+				fmt.Fprintf(&sb, `  <rect x="%g" y="%g" width="%g" height="%g" fill="black"/>`+"\n", cell*float64(col+1), cell*float64(row+1), cell, cell)
+			}
+		}
+	}
+	sb.WriteString("</svg>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// binarise separates the dark pixels from the light ones using Otsu's threshold
+func binarise(img *image.RGBA) []bool {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	luma := make([]uint8, width*height)
+	var histogram [256]int
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := img.PixOffset(bounds.Min.X+x, bounds.Min.Y+y)
+			l := uint8((299*int(img.Pix[i]) + 587*int(img.Pix[i+1]) + 114*int(img.Pix[i+2])) / 1000)
+			luma[y*width+x] = l
+			histogram[l]++
+		}
+	}
+
+	total := len(luma)
+	var sum float64
+	for i, count := range histogram {
+		sum += float64(i * count)
+	}
+	var sumBackground float64
+	var background int
+	var best float64
+	threshold := uint8(128)
+	for i, count := range histogram {
+		background += count
+		if background == 0 {
+			continue
+		}
+		foreground := total - background
+		if foreground == 0 {
+			break
+		}
+		sumBackground += float64(i * count)
+		meanBackground := sumBackground / float64(background)
+		meanForeground := (sum - sumBackground) / float64(foreground)
+		between := float64(background) * float64(foreground) * (meanBackground - meanForeground) * (meanBackground - meanForeground)
+		if between > best {
+			best = between
+			threshold = uint8(i)
+		}
+	}
+
+	dark := make([]bool, total)
+	for i, l := range luma {
+		dark[i] = l <= threshold
+	}
+	return dark
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
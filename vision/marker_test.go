@@ -0,0 +1,95 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// drawMarker draws the marker on a white frame, turned clockwise by the specified number of quarter turns
+func drawMarker(id, turns, cell int, at image.Point) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 320, 240))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	cells := markerCellsFor(id)
+	for row := 0; row < markerCells; row++ {
+		for col := 0; col < markerCells; col++ {
+			r, c := row, col
+			for i := 0; i < turns; i++ {
+				r, c = c, markerCells-1-r
+			}
+			if !cells[row][col] {
+				continue
+			}
+			for y := 0; y < cell; y++ {
+				for x := 0; x < cell; x++ {
+					img.Set(at.X+c*cell+x, at.Y+r*cell+y, color.Black)
+				}
+			}
+		}
+	}
+	return img
+}
+
+func TestMarkerCellsMatchTheArucoDictionary(t *testing.T) {
+	// The data cells of the original ArUco markers 0 and 1023, where true is a black cell
+	testCases := []struct {
+		id       int
+		expected [dataCells]bool
+	}{
+		{id: 0, expected: [dataCells]bool{false, true, true, true, true}},
+		{id: 1023, expected: [dataCells]bool{true, false, false, false, true}},
+	}
+	for _, tc := range testCases {
+		cells := markerCellsFor(tc.id)
+		for row := 1; row <= dataCells; row++ {
+			var actual [dataCells]bool
+			copy(actual[:], cells[row][1:markerCells-1])
+			if actual != tc.expected {
+				t.Errorf("Expected row %d of marker %d to be %v, got %v", row, tc.id, tc.expected, actual)
+			}
+		}
+	}
+}
+
+func TestDetectMarkers(t *testing.T) {
+	testCases := []struct {
+		title string
+		id    int
+		turns int
+	}{
+		{title: "upright", id: 3, turns: 0},
+		{title: "quarter turn", id: 3, turns: 1},
+		{title: "upside down", id: 690, turns: 2},
+		{title: "three quarter turn", id: 1023, turns: 3},
+		{title: "zero", id: 0, turns: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			markers := DetectMarkers(drawMarker(tc.id, tc.turns, 10, image.Pt(100, 60)))
+			if len(markers) != 1 {
+				t.Fatalf("Expected one marker, got %v", markers)
+			}
+			if markers[0].ID != tc.id {
+				t.Errorf("Expected marker %d, got %d", tc.id, markers[0].ID)
+			}
+			if expected := image.Rect(100, 60, 170, 130); markers[0].Bounds != expected {
+				t.Errorf("Expected the marker at %v, got %v", expected, markers[0].Bounds)
+			}
+		})
+	}
+}
+
+func TestDetectMarkersIgnoresOtherSquares(t *testing.T) {
+	img := drawMarker(0, 0, 10, image.Pt(100, 60))
+	// Fill the data cells, which is not a valid marker
+	for y := 70; y < 120; y++ {
+		for x := 110; x < 160; x++ {
+			img.Set(x, y, color.Black)
+		}
+	}
+	if markers := DetectMarkers(img); len(markers) != 0 {
+		t.Errorf("Expected no markers, got %v", markers)
+	}
+}
@@ -0,0 +1,157 @@
+package vision
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xitonix/gophobotics/event"
+	"github.com/xitonix/gophobotics/input"
+	"github.com/xitonix/gophobotics/logging"
+)
+
+// markersPublisher is the name the marker source publishes the events under
+const markersPublisher = "markers"
+
+// MarkerOptions are the settings of the marker source
+type MarkerOptions struct {
+	// Actions is the sequence of commands each marker triggers by marker ID. The markers without an action are only published.
+	Actions map[int][]input.Command
+	// Confirm is the number of frames in a row a marker must be seen in before it triggers its action
+	Confirm int
+	// Cooldown is how long a marker is ignored after it has triggered its action
+	Cooldown time.Duration
+}
+
+// DefaultMarkerOptions returns the marker settings with the specified actions
+func DefaultMarkerOptions(actions map[int][]input.Command) MarkerOptions {
+	return MarkerOptions{
+		Actions:  actions,
+		Confirm:  3,
+		Cooldown: 5 * time.Second,
+	}
+}
+
+// MarkerSource implements the Source interface and issues commands when the camera sees a printed marker.
+//
+// A marker must be seen in a few frames in a row before it counts, which filters out the misreads of a blurry frame.
+// A MarkerDetected event is published for every marker which comes into sight, and its sequence of commands is sent
+// one command at a time, each one once the previous one has been acknowledged. The rest of a sequence is dropped if a command
+// has not been carried out (ie. the pilot has taken over). The markers seen while a sequence is running are ignored.
+// The markers only steer the drone between the pilot's commands when the source is given a lower priority than the keyboard,
+// and a key press then cuts the running sequence short.
+type MarkerSource struct {
+	frames   <-chan Frame
+	commands chan input.Command
+	options  MarkerOptions
+	logger   *logging.Logger
+	bus      *event.Bus
+
+	streaks map[int]int
+	fired   map[int]time.Time
+
+	mux   sync.Mutex
+	queue []input.Command
+	gate  ackGate
+}
+
+// NewMarkerSource creates a new source which looks for the markers in the frames
+func NewMarkerSource(frames <-chan Frame, options MarkerOptions, logger *logging.Logger) *MarkerSource {
+	if options.Confirm < 1 {
+		options.Confirm = 1
+	}
+	return &MarkerSource{
+		frames:   frames,
+		commands: make(chan input.Command),
+		options:  options,
+		logger:   logger.With(logging.Fields{"source": markersPublisher}),
+		streaks:  make(map[int]int),
+		fired:    make(map[int]time.Time),
+	}
+}
+
+// SetEventBus sets the bus the detected markers and the triggered commands are published to
+func (m *MarkerSource) SetEventBus(bus *event.Bus) {
+	m.bus = bus
+}
+
+func (m *MarkerSource) Commands() <-chan input.Command {
+	return m.commands
+}
+
+// Start analyses the frames and blocks until the frames channel is closed
+func (m *MarkerSource) Start() error {
+	defer close(m.commands)
+	for frame := range m.frames {
+		m.detect(frame)
+		if cmd, ok := m.next(frame.At); ok {
+			m.bus.Publish(event.New(event.CommandTriggered, markersPublisher, cmd))
+			m.commands <- cmd
+		}
+	}
+	return nil
+}
+
+// Acknowledge allows the next command of the sequence to be sent. The rest of the sequence is dropped if the command has not been carried out.
+func (m *MarkerSource) Acknowledge(result input.Result) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.gate.acknowledge()
+	if result.Outcome != input.Executed && len(m.queue) > 0 {
+		m.logger.Infof("Markers: %d command(s) dropped after %s was not executed", len(m.queue), result.Command)
+		m.queue = nil
+	}
+}
+
+// detect updates how long each marker has been in sight and queues the action of the confirmed markers
+func (m *MarkerSource) detect(frame Frame) {
+	visible := make(map[int]Marker)
+	for _, marker := range DetectMarkers(frame.Image) {
+		visible[marker.ID] = marker
+	}
+	for id := range m.streaks {
+		if _, ok := visible[id]; !ok {
+			delete(m.streaks, id)
+		}
+	}
+	for id, marker := range visible {
+		m.streaks[id]++
+		if m.streaks[id] != m.options.Confirm {
+			continue
+		}
+		m.logger.Log(logging.Debug, logging.Fields{"marker": id, "bounds": marker.Bounds}, "Markers: marker %d detected", id)
+		m.bus.Publish(event.New(event.MarkerDetected, markersPublisher, marker))
+		m.trigger(id, frame.At)
+	}
+}
+
+// trigger queues the action of the marker unless it has fired recently or another action is running
+func (m *MarkerSource) trigger(id int, now time.Time) {
+	actions, ok := m.options.Actions[id]
+	if !ok || len(actions) == 0 {
+		return
+	}
+	if last, ok := m.fired[id]; ok && now.Sub(last) < m.options.Cooldown {
+		return
+	}
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if len(m.queue) > 0 {
+		return
+	}
+	m.fired[id] = now
+	m.queue = append([]input.Command(nil), actions...)
+	m.logger.Infof("Markers: marker %d triggered %v", id, actions)
+}
+
+// next returns the next command of the running sequence if the previous one has been acknowledged
+func (m *MarkerSource) next(now time.Time) (input.Command, bool) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if len(m.queue) == 0 || m.gate.waiting(now) {
+		return input.None, false
+	}
+	cmd := m.queue[0]
+	m.queue = m.queue[1:]
+	m.gate.send(now)
+	return cmd, true
+}
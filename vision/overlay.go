@@ -0,0 +1,137 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xitonix/gophobotics/input"
+)
+
+// Telemetry is the flight data drawn onto the frames
+type Telemetry struct {
+	// Airborne is true if the drone is flying
+	Airborne bool
+	// Height is the height of the drone in decimetres
+	Height int
+	// Speed is the horizontal speed of the drone in decimetres per second
+	Speed int
+	// Battery is the remaining battery percentage
+	Battery int
+	// Wifi is the strength of the Wi-Fi signal (0-100). Zero means it has not been reported.
+	Wifi int
+}
+
+type telemetrySample struct {
+	at        time.Time
+	telemetry Telemetry
+}
+
+type commandSample struct {
+	at      time.Time
+	command input.Command
+}
+
+// Timeline keeps the recent telemetry and commands, so they can be matched with the frames by timestamp.
+// The samples older than the span are discarded.
+type Timeline struct {
+	span time.Duration
+
+	mux       sync.Mutex
+	telemetry []telemetrySample
+	commands  []commandSample
+}
+
+// NewTimeline creates a new timeline which keeps the samples of the specified period
+func NewTimeline(span time.Duration) *Timeline {
+	return &Timeline{span: span}
+}
+
+// Record adds the telemetry received at the specified time
+func (t *Timeline) Record(at time.Time, telemetry Telemetry) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.telemetry = append(t.telemetry, telemetrySample{at: at, telemetry: telemetry})
+	for len(t.telemetry) > 1 && at.Sub(t.telemetry[0].at) > t.span {
+		t.telemetry = t.telemetry[1:]
+	}
+}
+
+// RecordCommand adds the command issued at the specified time
+func (t *Timeline) RecordCommand(at time.Time, command input.Command) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.commands = append(t.commands, commandSample{at: at, command: command})
+	// The latest command is kept however old it is
+	for len(t.commands) > 1 && at.Sub(t.commands[0].at) > t.span {
+		t.commands = t.commands[1:]
+	}
+}
+
+// At returns the latest telemetry and command recorded at or before the specified time.
+// The reported flag is false if no telemetry had been received by then.
+func (t *Timeline) At(at time.Time) (telemetry Telemetry, command input.Command, reported bool) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if i := sort.Search(len(t.telemetry), func(i int) bool { return t.telemetry[i].at.After(at) }); i > 0 {
+		telemetry, reported = t.telemetry[i-1].telemetry, true
+	}
+	if i := sort.Search(len(t.commands), func(i int) bool { return t.commands[i].at.After(at) }); i > 0 {
+		command = t.commands[i-1].command
+	}
+	return telemetry, command, reported
+}
+
+var (
+	overlayBackground = color.RGBA{A: 140}
+	overlayText       = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	overlayWarning    = color.RGBA{R: 255, G: 80, B: 60, A: 255}
+)
+
+// DrawOverlay writes the telemetry and the latest command onto a band at the bottom of the picture.
+// The battery is highlighted once it drops below the warning percentage.
+func DrawOverlay(img *image.RGBA, telemetry Telemetry, command input.Command, reported bool, batteryWarning int) {
+	bounds := img.Bounds()
+	scale := bounds.Dy() / 180
+	if scale < 1 {
+		scale = 1
+	}
+	line := (glyphHeight + 3) * scale
+	band := image.Rect(bounds.Min.X, bounds.Max.Y-2*line-2*scale, bounds.Max.X, bounds.Max.Y)
+	fill(img, band, overlayBackground)
+
+	x := bounds.Min.X + 2*scale
+	y := band.Min.Y + 2*scale
+	if !reported {
+		drawText(img, "NO TELEMETRY", image.Pt(x, y), scale, overlayText)
+	} else {
+		battery := overlayText
+		if telemetry.Battery < batteryWarning {
+			battery = overlayWarning
+		}
+		wifi := "WIFI --"
+		if telemetry.Wifi > 0 {
+			wifi = fmt.Sprintf("WIFI %d%%", telemetry.Wifi)
+		}
+		segments := []struct {
+			text   string
+			colour color.RGBA
+		}{
+			{fmt.Sprintf("H %.1fM", float64(telemetry.Height)/10), overlayText},
+			{fmt.Sprintf("SPD %.1fM/S", float64(telemetry.Speed)/10), overlayText},
+			{fmt.Sprintf("BAT %d%%", telemetry.Battery), battery},
+			{wifi, overlayText},
+		}
+		for _, segment := range segments {
+			drawText(img, segment.text, image.Pt(x, y), scale, segment.colour)
+			x += textWidth(segment.text+"  ", scale)
+		}
+	}
+
+	if command != input.None {
+		drawText(img, "CMD "+command.String(), image.Pt(bounds.Min.X+2*scale, y+line), scale, overlayText)
+	}
+}